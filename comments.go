@@ -0,0 +1,136 @@
+package jsonschema
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ExtractGoComments parses the Go package in directory dir and records the
+// doc comments of its types and struct fields in commentMap, keyed by
+// "<importPath>.<Type>" and "<importPath>.<Type>.<Field>" respectively.
+//
+// Parsed packages are cached by import path and absolute directory, so
+// extracting the comments of the same package repeatedly only parses it
+// once. ClearGoCommentCache drops them, e.g. once the sources changed.
+func ExtractGoComments(importPath, dir string, commentMap map[string]string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	comments, err := goComments.load(importPath, abs)
+	if err != nil {
+		return err
+	}
+	for name, text := range comments {
+		commentMap[importPath+"."+name] = text
+	}
+	return nil
+}
+
+// ClearGoCommentCache drops the packages parsed by ExtractGoComments.
+func ClearGoCommentCache() {
+	goComments.clear()
+}
+
+// goComments caches the comments of parsed packages.
+var goComments = &commentCache{packages: map[commentPackage]map[string]string{}}
+
+type commentPackage struct {
+	importPath, dir string
+}
+
+type commentCache struct {
+	mu       sync.Mutex
+	packages map[commentPackage]map[string]string
+}
+
+func (c *commentCache) load(importPath, dir string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := commentPackage{importPath: importPath, dir: dir}
+	if comments, ok := c.packages[key]; ok {
+		return comments, nil
+	}
+
+	comments, err := parseGoComments(dir)
+	if err != nil {
+		return nil, err
+	}
+	c.packages[key] = comments
+	return comments, nil
+}
+
+func (c *commentCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.packages = map[commentPackage]map[string]string{}
+}
+
+// parseGoComments returns the comments of the types and struct fields
+// declared in dir, keyed by "<Type>" and "<Type>.<Field>".
+func parseGoComments(dir string) (map[string]string, error) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := map[string]string{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					doc := typeSpec.Doc
+					if doc == nil && len(gen.Specs) == 1 {
+						doc = gen.Doc
+					}
+					addGoComment(comments, typeSpec.Name.Name, doc)
+
+					structType, ok := typeSpec.Type.(*ast.StructType)
+					if !ok {
+						continue
+					}
+					for _, field := range structType.Fields.List {
+						doc := field.Doc
+						if doc == nil {
+							doc = field.Comment
+						}
+						for _, name := range field.Names {
+							addGoComment(comments, typeSpec.Name.Name+"."+name.Name, doc)
+						}
+					}
+				}
+			}
+		}
+	}
+	return comments, nil
+}
+
+func addGoComment(comments map[string]string, name string, doc *ast.CommentGroup) {
+	if text := strings.TrimSpace(doc.Text()); text != "" {
+		comments[name] = text
+	}
+}
+
+// splitComment splits a comment into its first sentence, without the final
+// period, and the rest of it. The first sentence ends at the first period
+// followed by a space or a newline, or at the first blank line.
+func splitComment(comment string) (string, string) {
+	end, next := len(comment), len(comment)
+	for _, sep := range []string{". ", ".\n", "\n\n"} {
+		if i := strings.Index(comment, sep); i >= 0 && i < end {
+			end, next = i, i+len(sep)
+		}
+	}
+	title := strings.TrimSuffix(comment[:end], ".")
+	title = strings.Join(strings.Fields(title), " ")
+	return title, strings.TrimSpace(comment[next:])
+}
@@ -0,0 +1,126 @@
+package jsonschema
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const commentedSource = `package pets
+
+// Pet is a domestic animal.
+type Pet struct {
+	// Name the pet answers to.
+	Name string
+	Age  int // in years
+	Kind string
+}
+
+type (
+	// Owner owns pets.
+	Owner struct{ Pets []Pet }
+)
+`
+
+func TestExtractGoComments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonschema-comments")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pets.go"), []byte(commentedSource), 0644))
+
+	expected := map[string]string{
+		"example.com/pets.Pet":      "Pet is a domestic animal.",
+		"example.com/pets.Pet.Name": "Name the pet answers to.",
+		"example.com/pets.Pet.Age":  "in years",
+		"example.com/pets.Owner":    "Owner owns pets.",
+	}
+	commentMap := map[string]string{}
+	require.NoError(t, ExtractGoComments("example.com/pets", dir, commentMap))
+	require.Equal(t, expected, commentMap)
+
+	// the package is cached, so it is not parsed again once removed
+	require.NoError(t, os.RemoveAll(dir))
+	commentMap = map[string]string{}
+	require.NoError(t, ExtractGoComments("example.com/pets", dir, commentMap))
+	require.Equal(t, expected, commentMap)
+
+	require.Error(t, ExtractGoComments("example.com/missing", dir+"-missing", commentMap))
+
+	// the cache is dropped once cleared
+	ClearGoCommentCache()
+	require.Error(t, ExtractGoComments("example.com/pets", dir, commentMap))
+}
+
+func TestExtractGoCommentsRelativeDir(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+
+	for _, comment := range []string{"Pet is a domestic animal.", "Pet is a wild animal."} {
+		dir, err := ioutil.TempDir("", "jsonschema-comments")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		source := "package pets\n\n// " + comment + "\ntype Pet struct{}\n"
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "pets.go"), []byte(source), 0644))
+		require.NoError(t, os.Chdir(dir))
+
+		commentMap := map[string]string{}
+		require.NoError(t, ExtractGoComments("example.com/pets", ".", commentMap))
+		require.Equal(t, comment, commentMap["example.com/pets.Pet"])
+	}
+}
+
+func TestReflectGoComments(t *testing.T) {
+	r := &Reflector{}
+	require.NoError(t, r.AddGoComments("github.com/megaease/jsonschema", "."))
+
+	s := r.Reflect(&TestMapOfStruct{})
+	address := s.Definitions["Address"]
+	require.Equal(t, "Address is a postal address.", address.Description)
+	require.Equal(t, "Street holds the street and house number.", address.Properties["street"].Description)
+	require.Equal(t, "the city or town", address.Properties["city"].Description)
+
+	// explicit descriptions win over comments
+	s = r.Reflect(&TestUser{})
+	require.Equal(t, "list of IDs, omitted when empty", s.Definitions["TestUser"].Properties["friends"].Description)
+}
+
+func TestCommentTitles(t *testing.T) {
+	r := &Reflector{CommentTitles: true, CommentMap: map[string]string{
+		"github.com/megaease/jsonschema.Address":  "Address is a postal address. It is used for both\nshipping and billing.",
+		"github.com/megaease/jsonschema.TestUser": "TestUser is a user of\nthe service.",
+	}}
+
+	s := r.Reflect(&TestMapOfStruct{})
+	require.Equal(t, "Address is a postal address", s.Definitions["Address"].Title)
+	require.Equal(t, "It is used for both\nshipping and billing.", s.Definitions["Address"].Description)
+
+	s = r.Reflect(&TestUser{})
+	require.Equal(t, "TestUser is a user of the service", s.Definitions["TestUser"].Title)
+	require.Empty(t, s.Definitions["TestUser"].Description)
+}
+
+func TestSplitComment(t *testing.T) {
+	for comment, expected := range map[string][2]string{
+		"":                                  {"", ""},
+		"Pet is a domestic animal.":         {"Pet is a domestic animal", ""},
+		"Pet is a domestic animal":          {"Pet is a domestic animal", ""},
+		"Pet is an animal. It lives here.":  {"Pet is an animal", "It lives here."},
+		"Pet is an animal.\nIt lives here.": {"Pet is an animal", "It lives here."},
+		"Pet v1.2\n\nA domestic animal.":    {"Pet v1.2", "A domestic animal."},
+	} {
+		title, description := splitComment(comment)
+		require.Equal(t, expected, [2]string{title, description}, comment)
+	}
+}
+
+func BenchmarkExtractGoComments(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if err := ExtractGoComments("github.com/megaease/jsonschema", ".", map[string]string{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package compat tests that the schemas reflected by
+// github.com/megaease/jsonschema load in third-party validators as is. It is
+// a module of its own, so that the library does not depend on them.
+package compat
@@ -0,0 +1,16 @@
+module github.com/megaease/jsonschema/compat
+
+go 1.19
+
+require (
+	github.com/megaease/jsonschema v0.0.0
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	github.com/stretchr/testify v1.3.1-0.20190311161405-34c6fa2dc709
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
+
+replace github.com/megaease/jsonschema => ../
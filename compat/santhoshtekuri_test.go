@@ -0,0 +1,83 @@
+package compat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/megaease/jsonschema"
+	santhoshtekuri "github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type Address struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+}
+
+type User struct {
+	ID        int               `json:"id" jsonschema:"minimum=1"`
+	Name      string            `json:"name" jsonschema:"minLength=1,maxLength=20,example=joe"`
+	Email     string            `json:"email" jsonschema:"format=email"`
+	Website   string            `json:"website,omitempty" jsonschema:"format=uri"`
+	BirthDate time.Time         `json:"birth_date"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Home      *Address          `json:"home,omitempty"`
+	Friends   []int             `json:"friends,omitempty" jsonschema:"uniqueItems"`
+}
+
+// TestSanthoshTekuri pins that the github.com/santhosh-tekuri/jsonschema
+// validator loads the output of every Draft as is. It takes vocabularies
+// from meta-schemas, so formats are asserted through its AssertFormat.
+func TestSanthoshTekuri(t *testing.T) {
+	for _, draft := range []jsonschema.Draft{jsonschema.Draft04, jsonschema.Draft06, jsonschema.Draft07, jsonschema.Draft201909, jsonschema.Draft202012} {
+		r := &jsonschema.Reflector{Draft: draft}
+		b, err := json.Marshal(r.Reflect(&User{}))
+		require.NoError(t, err)
+
+		compiler := santhoshtekuri.NewCompiler()
+		compiler.AssertFormat = true
+		require.NoError(t, compiler.AddResource("user.json", strings.NewReader(string(b))))
+		schema, err := compiler.Compile("user.json")
+		require.NoError(t, err, draft)
+
+		var user interface{}
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"id": 1, "name": "joe", "email": "joe@example.com",
+			"website": "https://example.com", "birth_date": "2000-01-01T00:00:00Z",
+			"tags": {"role": "admin"}, "home": {"street": "Main", "city": "Springfield"},
+			"friends": [2, 3]
+		}`), &user))
+		require.NoError(t, schema.Validate(user), draft)
+
+		user.(map[string]interface{})["email"] = "joe"
+		require.Error(t, schema.Validate(user), draft)
+	}
+}
+
+// TestSanthoshTekuriMetaSchema pins that the validator takes format for an
+// assertion from the meta-schema of Reflector.MetaSchema, without
+// AssertFormat.
+func TestSanthoshTekuriMetaSchema(t *testing.T) {
+	r := &jsonschema.Reflector{Draft: jsonschema.Draft202012, FormatAssertion: true, MetaSchemaID: "https://example.com/meta"}
+	meta, err := json.Marshal(r.MetaSchema())
+	require.NoError(t, err)
+	b, err := json.Marshal(r.Reflect(&User{}))
+	require.NoError(t, err)
+
+	compiler := santhoshtekuri.NewCompiler()
+	require.NoError(t, compiler.AddResource("https://example.com/meta", strings.NewReader(string(meta))))
+	require.NoError(t, compiler.AddResource("user.json", strings.NewReader(string(b))))
+	schema, err := compiler.Compile("user.json")
+	require.NoError(t, err)
+
+	var user interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"id": 1, "name": "joe", "email": "joe@example.com", "birth_date": "2000-01-01T00:00:00Z"
+	}`), &user))
+	require.NoError(t, schema.Validate(user))
+
+	user.(map[string]interface{})["email"] = "joe"
+	require.Error(t, schema.Validate(user))
+}
@@ -0,0 +1,98 @@
+package jsonschema
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// genericTypeArgs splits an instantiated generic type such as
+// Optional[string] into the qualified name of its generic type and its type
+// arguments. Reflection does not expose type arguments, so they are resolved
+// by name against the types reachable from the instantiated type.
+func genericTypeArgs(t reflect.Type) (string, []reflect.Type, bool) {
+	name := t.Name()
+	open := strings.IndexByte(name, '[')
+	if open < 0 || !strings.HasSuffix(name, "]") {
+		return "", nil, false
+	}
+
+	base := name[:open]
+	if t.PkgPath() != "" {
+		base = t.PkgPath() + "." + base
+	}
+
+	reachable := map[string]reflect.Type{}
+	collectReachableTypes(t, reachable)
+
+	var args []reflect.Type
+	for _, arg := range splitTypeArgs(name[open+1 : len(name)-1]) {
+		args = append(args, reachable[arg])
+	}
+	return base, args, true
+}
+
+// splitTypeArgs splits a type argument list on the commas that are not
+// nested within brackets.
+func splitTypeArgs(list string) []string {
+	var args []string
+	depth, start := 0, 0
+	for i, c := range list {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(args, list[start:])
+}
+
+// collectReachableTypes records t and every type reachable from its
+// elements and fields, keyed by the name reflect uses for type arguments.
+func collectReachableTypes(t reflect.Type, reachable map[string]reflect.Type) {
+	name := qualifiedTypeName(t)
+	if _, ok := reachable[name]; ok {
+		return
+	}
+	reachable[name] = t
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		collectReachableTypes(t.Elem(), reachable)
+	case reflect.Map:
+		collectReachableTypes(t.Key(), reachable)
+		collectReachableTypes(t.Elem(), reachable)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			collectReachableTypes(t.Field(i).Type, reachable)
+		}
+	}
+}
+
+// qualifiedTypeName names t the way reflect prints type arguments, with
+// named types qualified by their full package path.
+func qualifiedTypeName(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		return t.PkgPath() + "." + t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + qualifiedTypeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + qualifiedTypeName(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + qualifiedTypeName(t.Elem())
+	case reflect.Map:
+		return "map[" + qualifiedTypeName(t.Key()) + "]" + qualifiedTypeName(t.Elem())
+	}
+	return t.String()
+}
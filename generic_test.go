@@ -0,0 +1,89 @@
+//go:build go1.18
+// +build go1.18
+
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type Optional[T any] struct {
+	Value T
+	Valid bool
+}
+
+type Pair[K comparable, V any] struct {
+	Values map[K][]V
+}
+
+type TestGeneric struct {
+	Nickname Optional[string] `json:"nickname"`
+	Age      Optional[int]    `json:"age"`
+}
+
+func TestGenericTypeArgs(t *testing.T) {
+	base, args, ok := genericTypeArgs(reflect.TypeOf(Optional[*Address]{}))
+	require.True(t, ok)
+	require.Equal(t, "github.com/megaease/jsonschema.Optional", base)
+	require.Equal(t, []reflect.Type{reflect.TypeOf(&Address{})}, args)
+
+	base, args, ok = genericTypeArgs(reflect.TypeOf(Pair[string, Optional[int]]{}))
+	require.True(t, ok)
+	require.Equal(t, "github.com/megaease/jsonschema.Pair", base)
+	require.Equal(t, []reflect.Type{reflect.TypeOf(""), reflect.TypeOf(Optional[int]{})}, args)
+
+	_, _, ok = genericTypeArgs(reflect.TypeOf(Address{}))
+	require.False(t, ok)
+}
+
+func TestGenericMapper(t *testing.T) {
+	r := &Reflector{
+		ExpandedStruct: true,
+		GenericMapper: func(base string, args []reflect.Type) *Type {
+			if base != "github.com/megaease/jsonschema.Optional" {
+				return nil
+			}
+			nullable := &Type{OneOf: []*Type{{Type: "null"}}}
+			switch args[0].Kind() {
+			case reflect.String:
+				nullable.OneOf = append(nullable.OneOf, &Type{Type: "string"})
+			case reflect.Int:
+				nullable.OneOf = append(nullable.OneOf, &Type{Type: "integer"})
+			}
+			return nullable
+		},
+	}
+	s := r.Reflect(&TestGeneric{})
+	require.Equal(t, &Type{OneOf: []*Type{{Type: "null"}, {Type: "string"}}}, s.Properties["nickname"])
+	require.Equal(t, &Type{OneOf: []*Type{{Type: "null"}, {Type: "integer"}}}, s.Properties["age"])
+	require.Empty(t, s.Definitions)
+}
+
+type Response[T any] struct {
+	Data  T      `json:"data"`
+	Error string `json:"error,omitempty"`
+}
+
+func TestTypeNameFunc(t *testing.T) {
+	r := &Reflector{TypeNameFunc: func(t reflect.Type) string {
+		base, args, ok := genericTypeArgs(t)
+		if !ok {
+			return ""
+		}
+		name := base[strings.LastIndex(base, ".")+1:]
+		for _, arg := range args {
+			name += "Of" + arg.Name()
+		}
+		return name
+	}}
+	s := r.Reflect(&Response[Address]{})
+	require.Equal(t, "#/definitions/ResponseOfAddress", s.Ref)
+	require.Contains(t, s.Definitions, "ResponseOfAddress")
+	require.Contains(t, s.Definitions, "Address")
+	require.Equal(t, "#/definitions/Address", s.Definitions["ResponseOfAddress"].Properties["data"].Ref)
+	require.Len(t, s.Definitions, 2)
+}
@@ -0,0 +1,151 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// ReflectValue infers a Schema from a value, typically the result of
+// decoding a JSON document into an interface{}, rather than from its Go type.
+//
+// Objects become object schemas listing the observed keys; keys present in
+// every observed object are required. Arrays become array schemas whose items
+// schema unifies all elements, falling back to anyOf when elements disagree.
+func ReflectValue(v interface{}) *Schema {
+	t := inferType(reflect.ValueOf(v))
+	t.Version = Version
+	return &Schema{Type: t}
+}
+
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+func inferType(v reflect.Value) *Type {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		if v.IsNil() {
+			return &Type{Type: "null"}
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return &Type{Type: "null"}
+	}
+
+	if v.Type() == jsonNumberType {
+		if _, err := v.Interface().(json.Number).Int64(); err == nil {
+			return &Type{Type: "integer"}
+		}
+		return &Type{Type: "number"}
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		st := &Type{
+			Type:       "object",
+			Properties: map[string]*Type{},
+		}
+		for _, key := range v.MapKeys() {
+			if key.Kind() != reflect.String {
+				continue
+			}
+			name := key.String()
+			st.Properties[name] = inferType(v.MapIndex(key))
+			st.Required = append(st.Required, name)
+		}
+		sort.Strings(st.Required)
+		return st
+
+	case reflect.Slice, reflect.Array:
+		if v.Type() == byteSliceType {
+			return &Type{Type: "string", Media: &Type{BinaryEncoding: "base64"}}
+		}
+		at := &Type{Type: "array"}
+		for i := 0; i < v.Len(); i++ {
+			at.Items = mergeInferredTypes(at.Items, inferType(v.Index(i)))
+		}
+		return at
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Type{Type: "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		// decoded JSON numbers are always float64, so whole numbers are
+		// reported as integers and widened to number when merged.
+		if f := v.Float(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return &Type{Type: "integer"}
+		}
+		return &Type{Type: "number"}
+
+	case reflect.Bool:
+		return &Type{Type: "boolean"}
+
+	case reflect.String:
+		return &Type{Type: "string"}
+	}
+	panic("unsupported value of type " + v.Type().String())
+}
+
+// mergeInferredTypes unifies two inferred schemas into one that accepts
+// values matching either.
+func mergeInferredTypes(a, b *Type) *Type {
+	if a == nil {
+		return b
+	}
+
+	if a.AnyOf != nil {
+		for i, branch := range a.AnyOf {
+			if merged := mergeSameInferredTypes(branch, b); merged != nil {
+				a.AnyOf[i] = merged
+				return a
+			}
+		}
+		a.AnyOf = append(a.AnyOf, b)
+		return a
+	}
+
+	if merged := mergeSameInferredTypes(a, b); merged != nil {
+		return merged
+	}
+	return &Type{AnyOf: []*Type{a, b}}
+}
+
+// mergeSameInferredTypes merges two schemas of compatible types,
+// returning nil when they are not compatible.
+func mergeSameInferredTypes(a, b *Type) *Type {
+	switch {
+	case a.Type == "integer" && b.Type == "number":
+		return b
+	case a.Type == "number" && b.Type == "integer":
+		return a
+	case a.Type != b.Type:
+		return nil
+	}
+
+	switch a.Type {
+	case "object":
+		inB := map[string]bool{}
+		for _, name := range b.Required {
+			inB[name] = true
+		}
+		required := []string{}
+		for _, name := range a.Required {
+			if inB[name] {
+				required = append(required, name)
+			}
+		}
+		for name, property := range b.Properties {
+			a.Properties[name] = mergeInferredTypes(a.Properties[name], property)
+		}
+		a.Required = nil
+		if len(required) > 0 {
+			a.Required = required
+		}
+	case "array":
+		if b.Items != nil {
+			a.Items = mergeInferredTypes(a.Items, b.Items)
+		}
+	}
+	return a
+}
@@ -0,0 +1,93 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReflectValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		sample   string
+		expected string
+	}{
+		{"scalar", `"hello"`, `{"$schema":"http://json-schema.org/draft-04/schema#","type":"string"}`},
+		{"null", `null`, `{"$schema":"http://json-schema.org/draft-04/schema#","type":"null"}`},
+		{
+			"object",
+			`{"name":"joe","age":30,"score":1.5,"admin":false,"tags":["a","b"]}`,
+			`{
+				"$schema": "http://json-schema.org/draft-04/schema#",
+				"type": "object",
+				"required": ["admin", "age", "name", "score", "tags"],
+				"properties": {
+					"admin": {"type": "boolean"},
+					"age": {"type": "integer"},
+					"name": {"type": "string"},
+					"score": {"type": "number"},
+					"tags": {"type": "array", "items": {"type": "string"}}
+				}
+			}`,
+		},
+		{
+			"array of objects",
+			`[{"id":1,"name":"a"},{"id":2.5,"email":"b@example.com"}]`,
+			`{
+				"$schema": "http://json-schema.org/draft-04/schema#",
+				"type": "array",
+				"items": {
+					"type": "object",
+					"required": ["id"],
+					"properties": {
+						"email": {"type": "string"},
+						"id": {"type": "number"},
+						"name": {"type": "string"}
+					}
+				}
+			}`,
+		},
+		{
+			"mixed array",
+			`[1, "two", 3, null]`,
+			`{
+				"$schema": "http://json-schema.org/draft-04/schema#",
+				"type": "array",
+				"items": {
+					"anyOf": [{"type": "integer"}, {"type": "string"}, {"type": "null"}]
+				}
+			}`,
+		},
+		{"empty array", `[]`, `{"$schema":"http://json-schema.org/draft-04/schema#","type":"array"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sample interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.sample), &sample))
+
+			actualJSON, err := json.Marshal(ReflectValue(sample))
+			require.NoError(t, err)
+			require.JSONEq(t, tt.expected, string(actualJSON))
+		})
+	}
+}
+
+func TestReflectValueTypedContainers(t *testing.T) {
+	sample := map[string]interface{}{
+		"labels": map[string]string{"env": "prod"},
+		"ports":  []int{80, 443},
+	}
+	actualJSON, err := json.Marshal(ReflectValue(sample))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"type": "object",
+		"required": ["labels", "ports"],
+		"properties": {
+			"labels": {"type": "object", "required": ["env"], "properties": {"env": {"type": "string"}}},
+			"ports": {"type": "array", "items": {"type": "integer"}}
+		}
+	}`, string(actualJSON))
+}
@@ -0,0 +1,83 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// ReflectOpenAPIComponents reflects the given values into schemas to drop
+// into the components.schemas of an OpenAPI 3.0 document, see
+// Reflector.ReflectOpenAPIComponents.
+func ReflectOpenAPIComponents(vs ...interface{}) map[string]*Type {
+	return (&Reflector{}).ReflectOpenAPIComponents(vs...)
+}
+
+// ReflectOpenAPIComponents reflects the given values into schemas to drop
+// into the components.schemas of an OpenAPI 3.0 document, keyed by their
+// definition names. References point to #/components/schemas/, schemas
+// declare no $schema, the first example moves to example, the null of
+// Nullable pointers becomes nullable, and the values of maps are described
+// by additionalProperties, as OpenAPI 3.0 has no patternProperties. Values
+// of unnamed types, such as slices, have no key and panic.
+func (r *Reflector) ReflectOpenAPIComponents(vs ...interface{}) map[string]*Type {
+	c := *r
+	c.Draft = Draft04
+	c.RefFormat = JSONPointer
+	c.refPrefix = "#/components/schemas/"
+	c.OpenAPI30 = true
+	// every value is a component of its own, the root included
+	c.BundleOnly = false
+	c.ExpandedStruct = false
+
+	// the values share their definitions, so that distinct types of the
+	// same name are told apart, see definitionName
+	components := Definitions{}
+	for _, v := range vs {
+		t := reflect.TypeOf(v)
+		if root := c.reflectTypeToSchema(components, t); root.Ref == "" {
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			if t.Name() == "" {
+				panic("type " + t.String() + " has no name for components.schemas")
+			}
+			root.goType = t
+			components[uniqueDefinitionName(components, c.genDefinitionName(t), t)] = root
+		}
+	}
+	c.reflectReopenedDefinitions(components)
+
+	s := c.completeSchema(&Schema{Type: &Type{}, Definitions: components})
+	s.Walk(func(_ string, t *Type) {
+		t.Version = ""
+		// OpenAPI 3.0 has no null type, and ignores the siblings of $ref
+		if len(t.AnyOf) != 2 || t.AnyOf[1].Type != "null" {
+			return
+		}
+		if schema := t.AnyOf[0]; schema.Ref != "" {
+			*t = Type{AllOf: []*Type{schema}, Nullable: true}
+		} else {
+			*t = *schema
+			t.Nullable = true
+		}
+	})
+	// the values of maps are marshaled once converted, maps nested in them
+	// included, so the schemas are converted in reverse walk order
+	var types []*Type
+	s.Walk(func(_ string, t *Type) {
+		types = append(types, t)
+	})
+	for i := len(types) - 1; i >= 0; i-- {
+		t := types[i]
+		if len(t.PatternProperties) != 1 || len(t.AdditionalProperties) != 0 {
+			continue
+		}
+		for pattern, values := range t.PatternProperties {
+			// the pattern of keys, if any, cannot be expressed
+			t.AdditionalProperties, _ = json.Marshal(values)
+			delete(t.PatternProperties, pattern)
+		}
+		t.PatternProperties = nil
+	}
+	return components
+}
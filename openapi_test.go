@@ -0,0 +1,67 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type Pet struct {
+	Name  string  `json:"name" jsonschema:"example=rex"`
+	Owner *Person `json:"owner"`
+	Tag   *string `json:"tag"`
+
+	Attributes map[string]string         `json:"attributes"`
+	Friends    map[string]*Person        `json:"friends"`
+	Scores     map[string]map[string]int `json:"scores"`
+}
+
+type Person struct {
+	Name string `json:"name"`
+}
+
+type Pets []Pet
+
+func TestReflectOpenAPIComponents(t *testing.T) {
+	components := (&Reflector{Nullable: true}).ReflectOpenAPIComponents(&Pet{}, Pets{})
+	require.Len(t, components, 3)
+
+	pet := components["Pet"]
+	require.Empty(t, pet.Version)
+	require.Equal(t, "rex", pet.Properties["name"].Example)
+	require.Empty(t, pet.Properties["name"].Examples)
+	require.Equal(t, &Type{AllOf: []*Type{{Ref: "#/components/schemas/Person"}}, Nullable: true}, pet.Properties["owner"])
+	require.Equal(t, &Type{Type: "string", Nullable: true}, pet.Properties["tag"])
+	require.Nil(t, pet.Properties["attributes"].PatternProperties)
+	require.JSONEq(t, `{"type":"string"}`, string(pet.Properties["attributes"].AdditionalProperties))
+	require.JSONEq(t, `{"$ref":"#/components/schemas/Person"}`, string(pet.Properties["friends"].AdditionalProperties))
+	require.JSONEq(t, `{"type":"object","additionalProperties":{"type":"integer"}}`, string(pet.Properties["scores"].AdditionalProperties))
+
+	require.Equal(t, "object", components["Person"].Type)
+	require.Equal(t, "#/components/schemas/Pet", components["Pets"].Items.Ref)
+
+	require.PanicsWithValue(t, "type []jsonschema.Pet has no name for components.schemas", func() {
+		ReflectOpenAPIComponents([]Pet{})
+	})
+}
+
+func TestReflectOpenAPIComponentsRoots(t *testing.T) {
+	expected := ReflectOpenAPIComponents(&Pet{})
+	require.Equal(t, expected, (&Reflector{BundleOnly: true}).ReflectOpenAPIComponents(&Pet{}))
+	require.Equal(t, expected, (&Reflector{ExpandedStruct: true}).ReflectOpenAPIComponents(&Pet{}))
+	require.Equal(t, "object", expected["Pet"].Type)
+	require.Contains(t, expected["Pet"].Properties, "name")
+}
+
+func TestReflectOpenAPIComponentsNameCollision(t *testing.T) {
+	// a distinct type of the same name as the package level Pet
+	type Pet struct {
+		Species string `json:"species"`
+	}
+
+	components := ReflectOpenAPIComponents(&Pet{}, &Pets{})
+	require.Len(t, components, 4)
+	require.Contains(t, components["Pet"].Properties, "species")
+	require.Contains(t, components["Pet2"].Properties, "name")
+	require.Equal(t, "#/components/schemas/Pet2", components["Pets"].Items.Ref)
+}
@@ -0,0 +1,39 @@
+package jsonschema
+
+// An Option overrides a Reflector setting for a single Reflect call.
+type Option func(r *Reflector)
+
+// WithDraft targets the given JSON Schema draft.
+func WithDraft(draft Draft) Option {
+	return func(r *Reflector) { r.Draft = draft }
+}
+
+// WithExpandedStruct inlines the root struct instead of referencing its
+// definition.
+func WithExpandedStruct() Option {
+	return func(r *Reflector) { r.ExpandedStruct = true }
+}
+
+// WithAllowAdditionalProperties allows additional properties on objects.
+func WithAllowAdditionalProperties() Option {
+	return func(r *Reflector) { r.AllowAdditionalProperties = true }
+}
+
+// WithRequiredFromJSONSchemaTags infers required properties from the
+// jsonschema tags rather than from json omitempty.
+func WithRequiredFromJSONSchemaTags() Option {
+	return func(r *Reflector) { r.RequiredFromJSONSchemaTags = true }
+}
+
+// withOptions returns r itself when no options are given, or else a copy of
+// r with the options applied, leaving r untouched.
+func (r *Reflector) withOptions(opts []Option) *Reflector {
+	if len(opts) == 0 {
+		return r
+	}
+	c := *r
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
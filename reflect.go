@@ -3,17 +3,29 @@
 // If json tags are present on struct fields, they will be used to infer
 // property names and if a property is required (omitempty is present).
 //
+// Types implementing json.Marshaler may declare the JSON type they marshal
+// to by also implementing a JSONSchemaType() string method.
+//
 // [1] http://json-schema.org/latest/json-schema-validation.html
 package jsonschema
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"math"
 	"net"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // Version is the JSON Schema version.
@@ -21,6 +33,65 @@ import (
 // RFC draft-wright-json-schema-00, section 6
 var Version = "http://json-schema.org/draft-04/schema#"
 
+// Draft identifies the JSON Schema specification a Reflector targets.
+type Draft int
+
+// Supported JSON Schema drafts. Draft04 is the default.
+const (
+	Draft04 Draft = iota
+	Draft06
+	Draft07
+	Draft201909
+	Draft202012
+)
+
+// draftURIs are the meta-schema URIs declared by $schema for each Draft.
+var draftURIs = map[Draft]string{
+	Draft04:     "http://json-schema.org/draft-04/schema#",
+	Draft06:     "http://json-schema.org/draft-06/schema#",
+	Draft07:     "http://json-schema.org/draft-07/schema#",
+	Draft201909: "https://json-schema.org/draft/2019-09/schema",
+	Draft202012: "https://json-schema.org/draft/2020-12/schema",
+}
+
+// metaSchemaVocabularies are the vocabularies of the meta-schemas of the
+// drafts that have vocabularies, as declared by their standard meta-schema.
+// The format vocabulary comes last, so that FormatAssertion can replace it.
+var metaSchemaVocabularies = map[Draft][]string{
+	Draft201909: {"core", "applicator", "validation", "meta-data", "content", "format"},
+	Draft202012: {"core", "applicator", "unevaluated", "validation", "meta-data", "content", "format-annotation"},
+}
+
+// RefFormat selects how references to definitions are written.
+type RefFormat int
+
+const (
+	// JSONPointer refers to definitions by JSON pointer, as in
+	// #/definitions/User. It is the default.
+	JSONPointer RefFormat = iota
+	// URN refers to definitions by URN, as in urn:example:User, which is
+	// also declared as the $id of each definition.
+	URN
+	// AbsoluteURL refers to definitions by URL, as in
+	// https://example.com/schemas/User, which is also declared as the $id
+	// of each definition.
+	AbsoluteURL
+)
+
+// AdditionalProperties selects the additionalProperties of struct objects.
+type AdditionalProperties int
+
+const (
+	// AdditionalPropertiesFalse rejects properties not declared by the
+	// struct. It is the default, unless AllowAdditionalProperties is set.
+	AdditionalPropertiesFalse AdditionalProperties = iota
+	// AdditionalPropertiesTrue explicitly allows any other property.
+	AdditionalPropertiesTrue
+	// AdditionalPropertiesOmitted leaves the keyword out, which allows any
+	// other property as well.
+	AdditionalPropertiesOmitted
+)
+
 // Schema is the root schema.
 // RFC draft-wright-json-schema-00, section 4.5
 type Schema struct {
@@ -28,32 +99,113 @@ type Schema struct {
 	Definitions Definitions `json:"definitions,omitempty"`
 }
 
+// MarshalJSON marshals the definitions of s under $defs from draft 2019-09
+// on, which renamed definitions, and under definitions otherwise.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema
+	if !s.usesDefs() {
+		return json.Marshal(schema(s))
+	}
+	return json.Marshal(struct {
+		*Type
+		Definitions Definitions `json:"$defs,omitempty"`
+	}{s.Type, s.Definitions})
+}
+
+// UnmarshalJSON unmarshals s, taking its definitions from $defs from draft
+// 2019-09 on, see MarshalJSON.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	type schema Schema
+	var v schema
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	if Schema(v).usesDefs() && v.Definitions == nil {
+		v.Definitions, v.Defs = Definitions(v.Defs), nil
+	}
+	*s = Schema(v)
+	return nil
+}
+
+// definitionsKeyword returns the keyword holding the definitions of s.
+func (s Schema) definitionsKeyword() string {
+	if s.usesDefs() {
+		return "$defs"
+	}
+	return "definitions"
+}
+
+// usesDefs reports whether s declares draft 2019-09 or later, taking any
+// $schema other than those of the earlier drafts for a meta-schema of its
+// own, such as that of Reflector.MetaSchema.
+func (s Schema) usesDefs() bool {
+	if s.Type == nil || s.Version == "" || s.Version == Version {
+		return false
+	}
+	for d := Draft04; d < Draft201909; d++ {
+		if s.Version == draftURIs[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalIndentStable marshals the schema indented by two spaces with the
+// keys of every object sorted, including those nested in raw keywords such
+// as examples, so that equal schemas always marshal to the same bytes.
+// Arrays, such as enum and examples, keep their order.
+func (s *Schema) MarshalIndentStable() ([]byte, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
 // Type represents a JSON Schema object type.
 type Type struct {
 	// RFC draft-wright-json-schema-00
 	Version string `json:"$schema,omitempty"` // section 6.1
 	Ref     string `json:"$ref,omitempty"`    // section 7
+	// RFC draft-wright-json-schema-01
+	ID string `json:"$id,omitempty"` // section 9.2
+	// RFC draft-handrews-json-schema-01
+	Comment string `json:"$comment,omitempty"` // section 9
+	// RFC draft-handrews-json-schema-02
+	Vocabulary map[string]bool  `json:"$vocabulary,omitempty"` // section 8.1.2
+	Defs       map[string]*Type `json:"$defs,omitempty"`       // section 8.2.5
+	// RecursiveAnchor is only meaningful in meta-schemas, see MetaSchema.
+	RecursiveAnchor bool `json:"$recursiveAnchor,omitempty"` // section 8.2.4.2.2
+	// RFC draft-bhutton-json-schema-00
+	DynamicAnchor string `json:"$dynamicAnchor,omitempty"` // section 8.2.2
 	// RFC draft-wright-json-schema-validation-00, section 5
 	MultipleOf           int              `json:"multipleOf,omitempty"`           // section 5.1
-	Maximum              int              `json:"maximum,omitempty"`              // section 5.2
-	ExclusiveMaximum     bool             `json:"exclusiveMaximum,omitempty"`     // section 5.3
-	Minimum              int              `json:"minimum,omitempty"`              // section 5.4
-	ExclusiveMinimum     bool             `json:"exclusiveMinimum,omitempty"`     // section 5.5
-	MaxLength            int              `json:"maxLength,omitempty"`            // section 5.6
-	MinLength            int              `json:"minLength,omitempty"`            // section 5.7
+	Maximum              json.Number      `json:"maximum,omitempty"`              // section 5.2
+	ExclusiveMaximum     json.RawMessage  `json:"exclusiveMaximum,omitempty"`     // section 5.3
+	Minimum              json.Number      `json:"minimum,omitempty"`              // section 5.4
+	ExclusiveMinimum     json.RawMessage  `json:"exclusiveMinimum,omitempty"`     // section 5.5
+	MaxLength            *int             `json:"maxLength,omitempty"`            // section 5.6
+	MinLength            *int             `json:"minLength,omitempty"`            // section 5.7
 	Pattern              string           `json:"pattern,omitempty"`              // section 5.8
 	AdditionalItems      *Type            `json:"additionalItems,omitempty"`      // section 5.9
 	Items                *Type            `json:"items,omitempty"`                // section 5.9
-	MaxItems             int              `json:"maxItems,omitempty"`             // section 5.10
-	MinItems             int              `json:"minItems,omitempty"`             // section 5.11
+	MaxItems             *int             `json:"maxItems,omitempty"`             // section 5.10
+	MinItems             *int             `json:"minItems,omitempty"`             // section 5.11
 	UniqueItems          bool             `json:"uniqueItems,omitempty"`          // section 5.12
-	MaxProperties        int              `json:"maxProperties,omitempty"`        // section 5.13
-	MinProperties        int              `json:"minProperties,omitempty"`        // section 5.14
+	MaxProperties        *int             `json:"maxProperties,omitempty"`        // section 5.13
+	MinProperties        *int             `json:"minProperties,omitempty"`        // section 5.14
 	Required             []string         `json:"required,omitempty"`             // section 5.15
 	Properties           map[string]*Type `json:"properties,omitempty"`           // section 5.16
 	PatternProperties    map[string]*Type `json:"patternProperties,omitempty"`    // section 5.17
 	AdditionalProperties json.RawMessage  `json:"additionalProperties,omitempty"` // section 5.18
 	Dependencies         map[string]*Type `json:"dependencies,omitempty"`         // section 5.19
+	PropertyNames        *Type            `json:"propertyNames,omitempty"`        // draft-wright-json-schema-validation-01, section 6.22
 	Enum                 []interface{}    `json:"enum,omitempty"`                 // section 5.20
 	Type                 string           `json:"type,omitempty"`                 // section 5.21
 	AllOf                []*Type          `json:"allOf,omitempty"`                // section 5.22
@@ -61,26 +213,57 @@ type Type struct {
 	OneOf                []*Type          `json:"oneOf,omitempty"`                // section 5.24
 	Not                  *Type            `json:"not,omitempty"`                  // section 5.25
 	Definitions          Definitions      `json:"definitions,omitempty"`          // section 5.26
+	// RFC draft-handrews-json-schema-validation-01, section 6.6
+	If   *Type `json:"if,omitempty"`   // section 6.6.1
+	Then *Type `json:"then,omitempty"` // section 6.6.2
+	Else *Type `json:"else,omitempty"` // section 6.6.3
+	// RFC draft-bhutton-json-schema-00
+	PrefixItems []*Type `json:"prefixItems,omitempty"` // section 10.3.1.1
 	// RFC draft-wright-json-schema-validation-00, section 6, 7
 	Title       string        `json:"title,omitempty"`       // section 6.1
 	Description string        `json:"description,omitempty"` // section 6.1
 	Default     interface{}   `json:"default,omitempty"`     // section 6.2
 	Format      string        `json:"format,omitempty"`      // section 7
 	Examples    []interface{} `json:"examples,omitempty"`    // section 7.4
+	// OpenAPI Specification 3.0.3, section 4.7.24.1
+	Nullable bool        `json:"nullable,omitempty"`
+	Example  interface{} `json:"example,omitempty"`
+	// RFC draft-handrews-json-schema-validation-02, section 9
+	Deprecated bool `json:"deprecated,omitempty"` // section 9.3
+	ReadOnly   bool `json:"readOnly,omitempty"`   // section 9.4
+	WriteOnly  bool `json:"writeOnly,omitempty"`  // section 9.4
 	// RFC draft-wright-json-schema-hyperschema-00, section 4
 	Media          *Type  `json:"media,omitempty"`          // section 4.3
 	BinaryEncoding string `json:"binaryEncoding,omitempty"` // section 4.3
+	// RFC draft-handrews-json-schema-validation-02, section 8
+	ContentEncoding  string `json:"contentEncoding,omitempty"`  // section 8.3
+	ContentMediaType string `json:"contentMediaType,omitempty"` // section 8.4
+	ContentSchema    *Type  `json:"contentSchema,omitempty"`    // section 8.5
+
+	// goType is the Go type a definition was reflected from, telling apart
+	// distinct types of the same definition name.
+	goType reflect.Type
+	// reopens is the name of the definition a definition copies with other
+	// additionalProperties, see reflectOverriddenStruct.
+	reopens string
 }
 
 // Reflect reflects to Schema from a value using the default Reflector
-func Reflect(v interface{}) *Schema {
-	return ReflectFromType(reflect.TypeOf(v))
+func Reflect(v interface{}, opts ...Option) *Schema {
+	return ReflectFromType(reflect.TypeOf(v), opts...)
 }
 
 // ReflectFromType generates root schema using the default Reflector
-func ReflectFromType(t reflect.Type) *Schema {
+func ReflectFromType(t reflect.Type, opts ...Option) *Schema {
+	r := &Reflector{}
+	return r.ReflectFromType(t, opts...)
+}
+
+// ReflectBundle reflects the types of several values into the definitions
+// of a schema without a root type, see Reflector.ReflectBundle.
+func ReflectBundle(vs ...interface{}) *Schema {
 	r := &Reflector{}
-	return r.ReflectFromType(t)
+	return r.ReflectBundle(vs...)
 }
 
 // A Reflector reflects values into a Schema.
@@ -92,15 +275,93 @@ type Reflector struct {
 	// validated JSON is unmarshaled.
 	AllowAdditionalProperties bool
 
+	// AdditionalProperties selects whether struct objects reject, allow or
+	// leave out additionalProperties. AllowAdditionalProperties is a
+	// shorthand for AdditionalPropertiesTrue.
+	AdditionalProperties AdditionalProperties
+
 	// RequiredFromJSONSchemaTags will cause the Reflector to generate a schema
 	// that requires any key tagged with `jsonschema:required`, overriding the
 	// default of requiring any key *not* tagged with `json:,omitempty`.
+	//
+	// Fields tagged both `json:",omitempty"` and `jsonschema:"required"` are
+	// thus optional by default and required with RequiredFromJSONSchemaTags.
+	// Either way `jsonschema:"required=true"` or `jsonschema:"required=false"`
+	// settles it.
 	RequiredFromJSONSchemaTags bool
 
 	// ExpandedStruct will cause the toplevel definitions of the schema not
 	// be referenced itself to a definition.
 	ExpandedStruct bool
 
+	// RequiredSlicesNonEmpty will cause the Reflector to require at least one
+	// item in required array fields that carry no explicit minItems.
+	RequiredSlicesNonEmpty bool
+
+	// SetAsArray will cause the Reflector to generate an array of unique items
+	// for maps with empty struct values, such as map[int]struct{}, the idiom
+	// for sets. Such types are expected to marshal themselves as arrays.
+	SetAsArray bool
+
+	// SortEnums will cause the Reflector to sort enums whose values are all
+	// strings or all numbers, for deterministic output. Other enums keep
+	// their declared order.
+	SortEnums bool
+
+	// Nullable will cause the Reflector to also accept null for pointer
+	// fields, as anyOf their schema and type null.
+	Nullable bool
+
+	// DurationAsString will cause the Reflector to reflect time.Duration to
+	// its String form, e.g. "1h30m", for types marshaling durations so,
+	// instead of the integer nanoseconds of encoding/json.
+	DurationAsString bool
+
+	// MaxProperties, when positive, caps the number of properties of the
+	// object schema of a struct. The Reflector panics on structs exceeding
+	// it, guarding tooling against pathological types.
+	MaxProperties int
+
+	// AutoTitle will cause the Reflector to title properties without a
+	// title of their own with their humanized name, e.g. "Birth Date" for
+	// birth_date.
+	AutoTitle bool
+
+	// FormatByName, when set, is called with the name of every string
+	// property without a format of its own and returns the format to assign
+	// to it, if any. Formats of the jsonschema tags take precedence.
+	FormatByName func(fieldName string) string
+
+	// StructOmitemptyIsRequired will cause the Reflector to require struct
+	// fields tagged omitempty whose type is a struct, such as time.Time, as
+	// encoding/json never omits them. By default omitempty makes any field
+	// optional.
+	StructOmitemptyIsRequired bool
+
+	// NumberFormats will cause the Reflector to annotate float32 and float64
+	// with the float and double formats of OpenAPI.
+	NumberFormats bool
+
+	// OpenAPI30 will cause the Reflector to emit the first example of every
+	// schema under the singular example keyword of OpenAPI 3.0 instead of
+	// the examples array of JSON Schema.
+	OpenAPI30 bool
+
+	// EnumLengthBounds will cause the Reflector to bound the length of
+	// string enum fields by their shortest and longest values, unless the
+	// tags bound it.
+	EnumLengthBounds bool
+
+	// ExamplesFromEnum will cause the Reflector to fill the examples of
+	// enum fields without explicit examples with their enum values.
+	ExamplesFromEnum bool
+
+	// BundleOnly will cause the Reflector to generate a schema without a root
+	// type, holding the reflected types as definitions only, to be referenced
+	// by other documents. It takes precedence over ExpandedStruct. See
+	// ReflectBundle to bundle the types of several values.
+	BundleOnly bool
+
 	// IgnoredTypes defines a slice of types that should be ignored in the schema,
 	// switching to just allowing additional properties instead.
 	IgnoredTypes []interface{}
@@ -108,31 +369,368 @@ type Reflector struct {
 	// TypeMapper is a function that can be used to map custom Go types to jsconschema types.
 	TypeMapper func(reflect.Type) *Type
 
+	// GenericMapper is consulted for every instantiation of a generic type,
+	// so that all instantiations of one generic type can be mapped at once.
+	// base is the package qualified name of the generic type, without type
+	// arguments, e.g. "example.com/pkg.Optional". args holds the type
+	// arguments; an argument that does not appear within the instantiated
+	// type cannot be recovered through reflection and is nil.
+	GenericMapper func(base string, args []reflect.Type) *Type
+
+	// PredefinedDefinitions maps types defined elsewhere to their $ref, which
+	// is emitted for them instead of reflecting them into a definition.
+	PredefinedDefinitions map[reflect.Type]string
+
+	// TimeDescription, when set, describes every time.Time that carries no
+	// explicit description, e.g. "RFC 3339 timestamp".
+	TimeDescription string
+
+	// SkipErrorFields will cause the Reflector to leave fields of type error
+	// out of the schema. By default they are reflected as strings, holding
+	// the error message.
+	SkipErrorFields bool
+
+	// RefFormat selects how references to definitions are written. URN and
+	// AbsoluteURL references are formed by appending the definition name to
+	// RefBase, e.g. "urn:example" or "https://example.com/schemas".
+	RefFormat RefFormat
+	RefBase   string
+
+	// IntegerRangeBounds will cause the Reflector to bound integer types by
+	// the range of their Go type through minimum and maximum, e.g. -128 and
+	// 127 for int8. Explicit tags override these bounds.
+	IntegerRangeBounds bool
+
 	// DefinitionNameWithPackage is a swith to enable full-name, reduce the probability of duplicate names
 	DefinitionNameWithPackage bool
+
+	// WellKnownRefs will cause the Reflector to define time.Time once, as
+	// Timestamp, and to refer to that definition from every time field
+	// instead of repeating its schema. Tags overriding the format of time
+	// fields, such as format=date, are then ignored.
+	WellKnownRefs bool
+
+	// TypeNameFunc, when set, names the definitions of types, taking
+	// precedence over DefinitionNameWithPackage, e.g. to name the generic
+	// Response[User] ResponseOfUser. Types it returns no name for are named
+	// as by default.
+	TypeNameFunc func(t reflect.Type) string
+
+	// Draft selects the JSON Schema draft declared by $schema. The default,
+	// Draft04, declares the package level Version.
+	Draft Draft
+
+	// FormatAssertion will cause the meta-schema returned by MetaSchema to
+	// declare, through its $vocabulary, that format is an assertion rather
+	// than an annotation. A $vocabulary only takes effect in the meta-schema
+	// of a schema, so this requires a MetaSchemaID, and validators must be
+	// able to load that meta-schema. It only applies to Draft201909 and
+	// later, and validators of Draft201909 may still treat format as an
+	// annotation.
+	FormatAssertion bool
+
+	// MetaSchemaID, when set, is the $id of the meta-schema returned by
+	// MetaSchema, and makes $schema of the root refer to it instead of to
+	// the meta-schema of the Draft. It only applies to Draft201909 and
+	// later.
+	MetaSchemaID string
+
+	// StrictTags will cause the Reflector to panic when a jsonschema tag
+	// keyword does not apply to the field it annotates, such as a format on
+	// a numeric field. By default such keywords are dropped.
+	StrictTags bool
+
+	// CommentMap holds Go comments, keyed as by ExtractGoComments, used to
+	// describe struct definitions and properties lacking a description.
+	CommentMap map[string]string
+
+	// CommentTitles will cause the Reflector to title struct definitions
+	// with the first sentence of their Go comment, leaving the rest of the
+	// comment as their description.
+	CommentTitles bool
+
+	// RootComment, when set, is the $comment of the root schema, e.g. to
+	// record where and when the schema was generated.
+	RootComment string
+
+	// RootExample, when set, is marshaled to JSON and attached to the
+	// examples of the root schema, documenting a complete valid document.
+	RootExample interface{}
+
+	// oneOfRequired holds the groups registered by AddOneOfRequired.
+	oneOfRequired map[reflect.Type][][][]string
+
+	// enums holds the string forms of the types registered by
+	// RegisterStringerEnum.
+	enums map[reflect.Type][]interface{}
+
+	// types holds the types registered by RegisterType by definition name.
+	types map[string]reflect.Type
+
+	// refPrefix, when set, replaces #/definitions/ in the JSON pointers to
+	// definitions, as for ReflectOpenAPIComponents.
+	refPrefix string
+
+	// deprecatedTypes holds the types registered by DeprecateType.
+	deprecatedTypes map[reflect.Type]bool
+
+	// tuples holds the schemas registered by AddTupleSchema.
+	tuples map[string]*Type
+
+	// variants holds the conditions registered by AddVariant.
+	variants map[reflect.Type][]*Type
+
+	// anyOfs holds the schemas registered by AddAnyOf, by struct type and
+	// property name.
+	anyOfs map[reflect.Type]map[string][]*Type
+
+	// fieldSchemas holds the schemas registered by AddFieldSchema, by struct
+	// type and property name.
+	fieldSchemas map[reflect.Type]map[string]*Type
 }
 
-// Reflect reflects to Schema from a value.
-func (r *Reflector) Reflect(v interface{}) *Schema {
-	return r.ReflectFromType(reflect.TypeOf(v))
+// AddFieldSchema reflects the property named field of the struct type of
+// structType to schema instead of reflecting its Go type, e.g. to document
+// the expected contents of a json.RawMessage. Tags of the field still apply.
+func (r *Reflector) AddFieldSchema(structType interface{}, field string, schema *Type) {
+	t := reflect.TypeOf(structType)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if r.fieldSchemas == nil {
+		r.fieldSchemas = map[reflect.Type]map[string]*Type{}
+	}
+	if r.fieldSchemas[t] == nil {
+		r.fieldSchemas[t] = map[string]*Type{}
+	}
+	r.fieldSchemas[t][field] = schema
 }
 
-// ReflectFromType generates root schema
-func (r *Reflector) ReflectFromType(t reflect.Type) *Schema {
-	definitions := Definitions{}
-	if r.ExpandedStruct {
-		st := &Type{
-			Version:              Version,
-			Type:                 "object",
-			Properties:           map[string]*Type{},
-			AdditionalProperties: []byte("false"),
+// AddAnyOf reflects the property named field of the struct type of
+// structType to an anyOf of schemas, for fields accepting any of several
+// shapes, instead of reflecting its Go type. Tags of the field still apply.
+func (r *Reflector) AddAnyOf(structType interface{}, field string, schemas ...*Type) {
+	t := reflect.TypeOf(structType)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if r.anyOfs == nil {
+		r.anyOfs = map[reflect.Type]map[string][]*Type{}
+	}
+	if r.anyOfs[t] == nil {
+		r.anyOfs[t] = map[string][]*Type{}
+	}
+	r.anyOfs[t][field] = append(r.anyOfs[t][field], schemas...)
+}
+
+// AddVariant requires objects of the struct type of structType whose
+// discriminator property equals value to also carry requiredFields.
+// Variants are expressed as if/then conditions, which require Draft07 or
+// later to be understood by validators.
+func (r *Reflector) AddVariant(structType interface{}, discriminatorField, value string, requiredFields []string) {
+	t := reflect.TypeOf(structType)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if r.variants == nil {
+		r.variants = map[reflect.Type][]*Type{}
+	}
+	r.variants[t] = append(r.variants[t], &Type{
+		If: &Type{
+			Properties: map[string]*Type{
+				discriminatorField: {Enum: []interface{}{value}},
+			},
+			Required: []string{discriminatorField},
+		},
+		Then: &Type{Required: requiredFields},
+	})
+}
+
+// AddTupleSchema reflects the array field, named by its definition and
+// property names joined by a dot such as "LogEntry.args", to a tuple whose
+// leading elements match prefix and remaining elements match rest. When rest
+// is nil the remaining elements match the reflected element type.
+// Tuples are expressed with prefixItems and thus require Draft202012.
+func (r *Reflector) AddTupleSchema(field string, prefix []*Type, rest *Type) {
+	if r.tuples == nil {
+		r.tuples = map[string]*Type{}
+	}
+	r.tuples[field] = &Type{PrefixItems: prefix, Items: rest}
+}
+
+// DeprecateType marks the definition of the struct type of v as deprecated.
+func (r *Reflector) DeprecateType(v interface{}) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if r.deprecatedTypes == nil {
+		r.deprecatedTypes = map[reflect.Type]bool{}
+	}
+	r.deprecatedTypes[t] = true
+}
+
+// RegisterType makes the type of v available to jsonschema tags by its
+// definition name, such as `jsonschema:"additionalProperties=Foo"` which
+// requires every additional property of an object to match Foo.
+func (r *Reflector) RegisterType(v interface{}) {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if r.types == nil {
+		r.types = map[string]reflect.Type{}
+	}
+	r.types[r.genDefinitionName(t)] = t
+}
+
+// RegisterStringerEnum reflects the type of zero, typically an integer type
+// with a String method generated by stringer, to a string enum of the String
+// forms of values.
+func (r *Reflector) RegisterStringerEnum(zero interface{}, values ...interface{}) {
+	names := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		stringer, ok := v.(fmt.Stringer)
+		if !ok {
+			panic(fmt.Sprintf("enum value %v of type %T does not implement fmt.Stringer", v, v))
 		}
-		if r.AllowAdditionalProperties {
-			st.AdditionalProperties = []byte("true")
+		names = append(names, stringer.String())
+	}
+	r.registerEnum(reflect.TypeOf(zero), names)
+}
+
+// RegisterIotaEnum reflects the type of zero, an integer type of constants
+// declared with iota from zero and without a String method, to a string enum
+// of names, the name of each constant given in order of value. Values are
+// expected to marshal to their names, e.g. through a MarshalText method.
+func (r *Reflector) RegisterIotaEnum(zero interface{}, names []string) {
+	t := reflect.TypeOf(zero)
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		panic(fmt.Sprintf("enum type %s is not an integer type", t))
+	}
+	enum := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		enum = append(enum, name)
+	}
+	r.registerEnum(t, enum)
+}
+
+func (r *Reflector) registerEnum(t reflect.Type, names []interface{}) {
+	if r.enums == nil {
+		r.enums = map[reflect.Type][]interface{}{}
+	}
+	r.enums[t] = names
+}
+
+// AddGoComments extracts the comments of the Go package in directory dir,
+// imported as importPath, into the CommentMap of the Reflector.
+func (r *Reflector) AddGoComments(importPath, dir string) error {
+	if r.CommentMap == nil {
+		r.CommentMap = map[string]string{}
+	}
+	return ExtractGoComments(importPath, dir, r.CommentMap)
+}
+
+// AddOneOfRequired requires objects of the struct type of structType to
+// carry exactly one of the groups of properties, excluding the properties
+// of every other group. Each call adds an independent constraint.
+func (r *Reflector) AddOneOfRequired(structType interface{}, groups ...[]string) {
+	t := reflect.TypeOf(structType)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if r.oneOfRequired == nil {
+		r.oneOfRequired = map[reflect.Type][][][]string{}
+	}
+	r.oneOfRequired[t] = append(r.oneOfRequired[t], groups)
+}
+
+// Reflect reflects to Schema from a value. The options apply to this call
+// only and leave r unchanged.
+func (r *Reflector) Reflect(v interface{}, opts ...Option) *Schema {
+	return r.ReflectFromType(reflect.TypeOf(v), opts...)
+}
+
+// ReflectFromType generates root schema. The options apply to this call
+// only and leave r unchanged.
+func (r *Reflector) ReflectFromType(t reflect.Type, opts ...Option) *Schema {
+	r = r.withOptions(opts)
+	return r.completeSchema(r.reflectRoot(t))
+}
+
+// ReflectBundle reflects the types of several values, unrelated or not,
+// into the definitions of a schema without a root type, to be referenced
+// by other documents, as BundleOnly does for the types of a single value.
+func (r *Reflector) ReflectBundle(vs ...interface{}) *Schema {
+	types := make([]reflect.Type, len(vs))
+	for i, v := range vs {
+		types[i] = reflect.TypeOf(v)
+	}
+	return r.completeSchema(r.reflectBundle(types...))
+}
+
+// completeSchema adds the keywords of the Reflector applying to the root of
+// s, and those applying to every schema once all of them are reflected.
+func (r *Reflector) completeSchema(s *Schema) *Schema {
+	if _, ok := metaSchemaVocabularies[r.Draft]; ok && r.MetaSchemaID != "" {
+		s.Version = r.MetaSchemaID
+	}
+	if r.RootComment != "" {
+		s.Comment = r.RootComment
+	}
+	if r.RootExample != nil {
+		example, err := json.Marshal(r.RootExample)
+		if err != nil {
+			panic("invalid root example: " + err.Error())
 		}
+		s.Examples = append(s.Examples, json.RawMessage(example))
+	}
+	if r.OpenAPI30 {
+		s.Walk(func(_ string, t *Type) {
+			if len(t.Examples) > 0 {
+				t.Example, t.Examples = t.Examples[0], nil
+			}
+		})
+	}
+	return s
+}
+
+// ReflectPair reflects v once into both its full schema and a minimal one
+// for validation only, a copy without the title, description, $comment and
+// examples of any of its schemas.
+func (r *Reflector) ReflectPair(v interface{}, opts ...Option) (full, minimal *Schema) {
+	full = r.Reflect(v, opts...)
+	b, err := json.Marshal(full)
+	if err != nil {
+		panic("invalid schema: " + err.Error())
+	}
+	minimal = &Schema{}
+	if err := json.Unmarshal(b, minimal); err != nil {
+		panic("invalid schema: " + err.Error())
+	}
+	minimal.Walk(func(_ string, t *Type) {
+		t.Title, t.Description, t.Comment = "", "", ""
+		t.Examples, t.Example = nil, nil
+	})
+	return full, minimal
+}
+
+func (r *Reflector) reflectRoot(t reflect.Type) *Schema {
+	if r.BundleOnly {
+		return r.reflectBundle(t)
+	}
+	definitions := Definitions{}
+	if r.ExpandedStruct {
+		st := r.newObjectType()
+		st.Version = r.version()
 		r.reflectStructFields(st, definitions, t)
+		r.reflectStructConstraints(st, t)
 		r.reflectStruct(definitions, t)
-		delete(definitions, r.genDefinitionName(t))
+		r.reflectReopenedDefinitions(definitions)
+		delete(definitions, r.definitionName(definitions, t))
 		return &Schema{Type: st, Definitions: definitions}
 	}
 
@@ -140,9 +738,23 @@ func (r *Reflector) ReflectFromType(t reflect.Type) *Schema {
 		Type:        r.reflectTypeToSchema(definitions, t),
 		Definitions: definitions,
 	}
+	r.reflectReopenedDefinitions(definitions)
+	// roots other than structs, such as slices, declare $schema too
+	s.Version = r.version()
 	return s
 }
 
+// reflectBundle reflects types into the definitions of a schema without a
+// root type.
+func (r *Reflector) reflectBundle(types ...reflect.Type) *Schema {
+	definitions := Definitions{}
+	for _, t := range types {
+		r.reflectTypeToSchema(definitions, t)
+	}
+	r.reflectReopenedDefinitions(definitions)
+	return &Schema{Type: &Type{Version: r.version()}, Definitions: definitions}
+}
+
 // Definitions hold schema definitions.
 // http://json-schema.org/latest/json-schema-validation.html#rfc.section.5.26
 // RFC draft-wright-json-schema-validation-00, section 5.26
@@ -166,7 +778,162 @@ type protoEnum interface {
 
 var protoEnumType = reflect.TypeOf((*protoEnum)(nil)).Elem()
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durationPattern matches the String form of time.Duration.
+const durationPattern = `^[-+]?(0|([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$`
+
+// Types marshaling themselves to JSON may declare the JSON type they marshal
+// to, e.g. "string", through this interface.
+type schemaTyper interface {
+	json.Marshaler
+	JSONSchemaType() string
+}
+
+var schemaTyperType = reflect.TypeOf((*schemaTyper)(nil)).Elem()
+
+// unserializableTypes cannot be meaningfully marshaled to JSON; struct
+// fields holding them are left out of the schema.
+var unserializableTypes = []reflect.Type{
+	reflect.TypeOf(sync.Mutex{}),
+	reflect.TypeOf(sync.RWMutex{}),
+	reflect.TypeOf(sync.WaitGroup{}),
+	reflect.TypeOf(sync.Once{}),
+}
+
+// unserializableInterfaces are interfaces whose implementations cannot be
+// meaningfully marshaled to JSON.
+var unserializableInterfaces = []reflect.Type{
+	reflect.TypeOf((*context.Context)(nil)).Elem(),
+	reflect.TypeOf((*io.Reader)(nil)).Elem(),
+	reflect.TypeOf((*io.Writer)(nil)).Elem(),
+	reflect.TypeOf((*io.Closer)(nil)).Elem(),
+}
+
+// unserializable reports whether values of type t cannot be marshaled to
+// JSON, such as channels, functions, mutexes and contexts.
+func unserializable(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return true
+	case reflect.Interface:
+		for _, it := range unserializableInterfaces {
+			if t.Implements(it) {
+				return true
+			}
+		}
+	}
+	for _, ut := range unserializableTypes {
+		if t == ut {
+			return true
+		}
+	}
+	return false
+}
+
+// MetaSchema returns the companion meta-schema that the root schemas of r
+// refer to by MetaSchemaID. It extends the meta-schema of the Draft, and
+// declares format an assertion if FormatAssertion is set. Validators must
+// be given it alongside the schemas it describes. It returns nil for drafts
+// before Draft201909, which have no vocabularies.
+func (r *Reflector) MetaSchema() *Schema {
+	vocabularies, ok := metaSchemaVocabularies[r.Draft]
+	if !ok {
+		return nil
+	}
+	base := strings.TrimSuffix(draftURIs[r.Draft], "schema")
+	t := &Type{
+		Version:    draftURIs[r.Draft],
+		ID:         r.MetaSchemaID,
+		Vocabulary: map[string]bool{},
+	}
+	if r.Draft == Draft201909 {
+		t.RecursiveAnchor = true
+	} else {
+		t.DynamicAnchor = "meta"
+	}
+	for _, vocabulary := range vocabularies {
+		t.AllOf = append(t.AllOf, &Type{Ref: base + "meta/" + vocabulary})
+		t.Vocabulary[base+"vocab/"+vocabulary] = vocabulary != "format"
+	}
+	if r.FormatAssertion {
+		format := vocabularies[len(vocabularies)-1]
+		delete(t.Vocabulary, base+"vocab/"+format)
+		if r.Draft == Draft201909 {
+			t.Vocabulary[base+"vocab/format"] = true
+		} else {
+			t.Vocabulary[base+"vocab/format-assertion"] = true
+		}
+	}
+	return &Schema{Type: t}
+}
+
+// version returns the $schema URI of the draft targeted by the Reflector.
+func (r *Reflector) version() string {
+	if r.Draft == Draft04 {
+		return Version
+	}
+	return draftURIs[r.Draft]
+}
+
+// definitionRef returns the $ref of the definition with the given name.
+func (r *Reflector) definitionRef(name string) string {
+	if id := r.definitionID(name); id != "" {
+		return id
+	}
+	if r.refPrefix != "" {
+		return r.refPrefix + name
+	}
+	if r.Draft >= Draft201909 {
+		return "#/$defs/" + name
+	}
+	return "#/definitions/" + name
+}
+
+// definitionID returns the $id declared by the definition with the given
+// name, which is empty when definitions are referenced by JSON pointer.
+func (r *Reflector) definitionID(name string) string {
+	switch r.RefFormat {
+	case URN:
+		return r.RefBase + ":" + name
+	case AbsoluteURL:
+		return strings.TrimSuffix(r.RefBase, "/") + "/" + name
+	}
+	return ""
+}
+
+// definitionName returns the name of the definition of t in definitions.
+// Distinct types of the same name, such as User of two packages, are told
+// apart by suffixing the names of later ones, as in User2.
+func (r *Reflector) definitionName(definitions Definitions, t reflect.Type) string {
+	return uniqueDefinitionName(definitions, r.genDefinitionName(t), t)
+}
+
+// uniqueDefinitionName returns name, suffixed when definitions hold a
+// definition of that name reflected from another type than t.
+func uniqueDefinitionName(definitions Definitions, name string, t reflect.Type) string {
+	for i, candidate := 2, name; ; i++ {
+		definition, ok := definitions[candidate]
+		if !ok || definition.goType == t {
+			return candidate
+		}
+		candidate = name + strconv.Itoa(i)
+	}
+}
+
 func (r *Reflector) genDefinitionName(t reflect.Type) string {
+	if r.TypeNameFunc != nil {
+		if name := r.TypeNameFunc(t); name != "" {
+			return name
+		}
+	}
 	if r.DefinitionNameWithPackage {
 		return t.String()
 	}
@@ -174,9 +941,21 @@ func (r *Reflector) genDefinitionName(t reflect.Type) string {
 }
 
 func (r *Reflector) reflectTypeToSchema(definitions Definitions, t reflect.Type) *Type {
+	if ref, ok := r.PredefinedDefinitions[t]; ok {
+		return &Type{Ref: ref}
+	}
+
 	// Already added to definitions?
-	if _, ok := definitions[r.genDefinitionName(t)]; ok {
-		return &Type{Ref: "#/definitions/" + r.genDefinitionName(t)}
+	if name := r.definitionName(definitions, t); definitions[name] != nil {
+		return &Type{Ref: r.definitionRef(name)}
+	}
+
+	if names, ok := r.enums[t]; ok {
+		et := &Type{Type: "string", Enum: append([]interface{}(nil), names...)}
+		if r.SortEnums {
+			sortEnum(et.Enum)
+		}
+		return et
 	}
 
 	// jsonpb will marshal protobuf enum options as either strings or integers.
@@ -194,10 +973,40 @@ func (r *Reflector) reflectTypeToSchema(definitions Definitions, t reflect.Type)
 		}
 	}
 
+	// the zero value of an interface holds no type to ask for its hint
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface {
+		if t.Implements(schemaTyperType) {
+			return &Type{Type: reflect.Zero(t).Interface().(schemaTyper).JSONSchemaType()}
+		}
+		if reflect.PtrTo(t).Implements(schemaTyperType) {
+			return &Type{Type: reflect.New(t).Interface().(schemaTyper).JSONSchemaType()}
+		}
+	}
+
+	if r.GenericMapper != nil {
+		if base, args, ok := genericTypeArgs(t); ok {
+			if t := r.GenericMapper(base, args); t != nil {
+				return t
+			}
+		}
+	}
+
 	// Defined format types for JSON Schema Validation
 	// RFC draft-wright-json-schema-validation-00, section 7.3
 	// TODO email RFC section 7.3.2, hostname RFC section 7.3.3, uriref RFC section 7.3.7
 	switch t {
+	case errorType:
+		return &Type{Type: "string"}
+	case durationType:
+		if r.DurationAsString {
+			return &Type{Type: "string", Pattern: durationPattern}
+		}
+	case jsonNumberType:
+		// json.Number marshals as a bare number, not as a string
+		return &Type{Type: "number"}
+	case rawMessageType:
+		// json.RawMessage holds any JSON value
+		return &Type{}
 	case ipType:
 		// TODO differentiate ipv4 and ipv6 RFC section 7.3.4, 7.3.5
 		return &Type{Type: "string", Format: "ipv4"} // ipv4 RFC section 7.3.4
@@ -208,14 +1017,32 @@ func (r *Reflector) reflectTypeToSchema(definitions Definitions, t reflect.Type)
 
 		switch t {
 		case timeType: // date-time RFC section 7.3.1
-			return &Type{Type: "string", Format: "date-time"}
+			timestamp := &Type{Type: "string", Format: "date-time", Description: r.TimeDescription}
+			if r.WellKnownRefs {
+				name := uniqueDefinitionName(definitions, "Timestamp", timeType)
+				timestamp.ID = r.definitionID(name)
+				timestamp.goType = timeType
+				definitions[name] = timestamp
+				return &Type{Ref: r.definitionRef(name)}
+			}
+			return timestamp
 		case uriType: // uri RFC section 7.3.6
 			return &Type{Type: "string", Format: "uri"}
 		default:
+			if t.Name() == "" {
+				return r.reflectAnonymousStruct(definitions, t)
+			}
 			return r.reflectStruct(definitions, t)
 		}
 
 	case reflect.Map:
+		if r.SetAsArray && t.Elem().Kind() == reflect.Struct && t.Elem().NumField() == 0 {
+			return &Type{
+				Type:        "array",
+				Items:       r.reflectTypeToSchema(definitions, t.Key()),
+				UniqueItems: true,
+			}
+		}
 		rt := &Type{
 			Type: "object",
 			PatternProperties: map[string]*Type{
@@ -223,13 +1050,20 @@ func (r *Reflector) reflectTypeToSchema(definitions Definitions, t reflect.Type)
 			},
 		}
 		delete(rt.PatternProperties, "additionalProperties")
+		// named key types constrained by a TypeMapper or a registered enum
+		// constrain the property names
+		if key := t.Key(); key.Kind() == reflect.String && key.Name() != "" && key.PkgPath() != "" {
+			if names := r.reflectTypeToSchema(definitions, key); !reflect.DeepEqual(names, &Type{Type: "string"}) {
+				rt.PropertyNames = names
+			}
+		}
 		return rt
 
 	case reflect.Slice, reflect.Array:
 		returnType := &Type{}
 		if t.Kind() == reflect.Array {
-			returnType.MinItems = t.Len()
-			returnType.MaxItems = returnType.MinItems
+			returnType.MinItems = intPtr(t.Len())
+			returnType.MaxItems = intPtr(t.Len())
 		}
 		switch t {
 		case byteSliceType:
@@ -250,9 +1084,22 @@ func (r *Reflector) reflectTypeToSchema(definitions Definitions, t reflect.Type)
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return &Type{Type: "integer"}
+		it := &Type{Type: "integer"}
+		if r.IntegerRangeBounds {
+			it.Minimum, it.Maximum = integerRange(t)
+		}
+		return it
+
+	case reflect.Float32:
+		if r.NumberFormats {
+			return &Type{Type: "number", Format: "float"}
+		}
+		return &Type{Type: "number"}
 
-	case reflect.Float32, reflect.Float64:
+	case reflect.Float64:
+		if r.NumberFormats {
+			return &Type{Type: "number", Format: "double"}
+		}
 		return &Type{Type: "number"}
 
 	case reflect.Bool:
@@ -267,6 +1114,40 @@ func (r *Reflector) reflectTypeToSchema(definitions Definitions, t reflect.Type)
 	panic("unsupported type " + t.String())
 }
 
+// integerRange returns the smallest and largest values of an integer type.
+func integerRange(t reflect.Type) (json.Number, json.Number) {
+	bits := uint(t.Bits())
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		max := ^uint64(0) >> (64 - bits)
+		return "0", json.Number(strconv.FormatUint(max, 10))
+	}
+	max := int64(^uint64(0) >> (65 - bits))
+	return json.Number(strconv.FormatInt(-max-1, 10)), json.Number(strconv.FormatInt(max, 10))
+}
+
+// newObjectType returns an empty object schema for a struct.
+func (r *Reflector) newObjectType() *Type {
+	st := &Type{
+		Type:       "object",
+		Properties: map[string]*Type{},
+	}
+	switch {
+	case r.AllowAdditionalProperties || r.AdditionalProperties == AdditionalPropertiesTrue:
+		st.AdditionalProperties = []byte("true")
+	case r.AdditionalProperties == AdditionalPropertiesFalse:
+		st.AdditionalProperties = []byte("false")
+	}
+	return st
+}
+
+// Anonymous structs have no name to be defined by, so they are inlined.
+func (r *Reflector) reflectAnonymousStruct(definitions Definitions, t reflect.Type) *Type {
+	st := r.newObjectType()
+	r.reflectStructFields(st, definitions, t)
+	return st
+}
+
 // Refects a struct to a JSON Schema type.
 func (r *Reflector) reflectStruct(definitions Definitions, t reflect.Type) *Type {
 	for _, ignored := range r.IgnoredTypes {
@@ -276,53 +1157,214 @@ func (r *Reflector) reflectStruct(definitions Definitions, t reflect.Type) *Type
 				Properties:           map[string]*Type{},
 				AdditionalProperties: []byte("true"),
 			}
-			definitions[r.genDefinitionName(t)] = st
+			name := r.definitionName(definitions, t)
+			st.ID = r.definitionID(name)
+			st.goType = t
+			definitions[name] = st
 
 			return &Type{
-				Version: Version,
-				Ref:     "#/definitions/" + r.genDefinitionName(t),
+				Version: r.version(),
+				Ref:     r.definitionRef(name),
 			}
 
 		}
 	}
-	st := &Type{
-		Type:                 "object",
-		Properties:           map[string]*Type{},
-		AdditionalProperties: []byte("false"),
-	}
-	if r.AllowAdditionalProperties {
-		st.AdditionalProperties = []byte("true")
-	}
-	definitions[r.genDefinitionName(t)] = st
+	st := r.newObjectType()
+	name := r.definitionName(definitions, t)
+	st.ID = r.definitionID(name)
+	st.goType = t
+	definitions[name] = st
 	r.reflectStructFields(st, definitions, t)
+	r.reflectStructConstraints(st, t)
 
 	return &Type{
-		Version: Version,
-		Ref:     "#/definitions/" + r.genDefinitionName(t),
+		Version: r.version(),
+		Ref:     r.definitionRef(name),
+	}
+}
+
+// reflectStructConstraints adds the constraints registered for a struct
+// type to its object schema.
+func (r *Reflector) reflectStructConstraints(st *Type, t reflect.Type) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if r.deprecatedTypes[t] {
+		st.Deprecated = true
+	}
+	if st.Title == "" && st.Description == "" {
+		comment := r.CommentMap[t.PkgPath()+"."+t.Name()]
+		if r.CommentTitles {
+			st.Title, st.Description = splitComment(comment)
+		} else {
+			st.Description = comment
+		}
+	}
+	for _, groups := range r.oneOfRequired[t] {
+		oneOf := oneOfRequiredBranches(groups)
+		if st.OneOf == nil {
+			st.OneOf = oneOf
+		} else {
+			st.AllOf = append(st.AllOf, &Type{OneOf: oneOf})
+		}
 	}
+	st.AllOf = append(st.AllOf, r.variants[t]...)
+}
+
+// oneOfRequiredBranches builds a oneOf branch per group, requiring the
+// properties of the group and forbidding those of the other groups.
+func oneOfRequiredBranches(groups [][]string) []*Type {
+	branches := make([]*Type, 0, len(groups))
+	for i, group := range groups {
+		inGroup := map[string]bool{}
+		for _, name := range group {
+			inGroup[name] = true
+		}
+		branch := &Type{Required: group}
+		var excluded []*Type
+		for j, other := range groups {
+			if i == j {
+				continue
+			}
+			for _, name := range other {
+				if !inGroup[name] {
+					excluded = append(excluded, &Type{Required: []string{name}})
+				}
+			}
+		}
+		if len(excluded) > 0 {
+			branch.Not = &Type{AnyOf: excluded}
+		}
+		branches = append(branches, branch)
+	}
+	return branches
 }
 
 func (r *Reflector) reflectStructFields(st *Type, definitions Definitions, t reflect.Type) {
+	r.reflectPromotedFields(st, definitions, t, nil)
+	if r.MaxProperties > 0 && len(st.Properties) > r.MaxProperties {
+		panic(fmt.Sprintf("%s has %d properties, more than MaxProperties %d", t, len(st.Properties), r.MaxProperties))
+	}
+}
+
+// reflectPromotedFields reflects the fields of t into st, leaving out the
+// names in shadowed. As in Go, the fields of embedded structs are shadowed
+// by the fields of the embedding struct of the same name.
+func (r *Reflector) reflectPromotedFields(st *Type, definitions Definitions, t reflect.Type, shadowed map[string]bool) {
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
 	if t.Kind() != reflect.Struct {
 		return
 	}
+
+	outer := map[string]bool{}
+	for name := range shadowed {
+		outer[name] = true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := r.reflectFieldName(t.Field(i)); name != "" {
+			outer[name] = true
+		}
+	}
+
 	for i := 0; i < t.NumField(); i++ {
 		f := t.Field(i)
 		name, exist, required := r.reflectFieldName(f)
 		// if anonymous and exported type should be processed recursively
-		// current type should inherit properties of anonymous one
+		// current type should inherit properties of anonymous one.
+		// Untagged anonymous types that are not structs, such as maps,
+		// have no properties to inherit and are ignored.
 		if name == "" {
 			if f.Anonymous && !exist {
-				r.reflectStructFields(st, definitions, f.Type)
+				r.reflectPromotedFields(st, definitions, f.Type, outer)
+			}
+			continue
+		}
+		if shadowed[name] {
+			continue
+		}
+		if r.SkipErrorFields && f.Type == errorType {
+			continue
+		}
+		if unserializable(f.Type) {
+			if r.StrictTags {
+				panic(fmt.Sprintf("field %s of unserializable type %s", f.Name, f.Type))
 			}
 			continue
 		}
 
-		property := r.reflectTypeToSchema(definitions, f.Type)
+		property := stringEncodedType(f)
+		if anyOf, ok := r.anyOfs[t][name]; ok {
+			property = &Type{AnyOf: anyOf}
+		}
+		if schema, ok := r.fieldSchemas[t][name]; ok {
+			// tags modify the property, not the registered schema
+			copied := *schema
+			property = &copied
+		}
+		timeField := false
+		if property == nil {
+			property = r.reflectTypeToSchema(definitions, f.Type)
+			// the tags and comments of time fields describe them first
+			if property.Ref == "" && r.TimeDescription != "" && property.Description == r.TimeDescription {
+				property.Description, timeField = "", true
+			}
+		}
+		if property.Ref != "" {
+			property = r.reflectOverriddenStruct(property, definitions, f)
+		}
+		nullable := r.Nullable && f.Type.Kind() == reflect.Ptr
+		if nullable && property.Ref != "" {
+			// $ref siblings are ignored before draft 2019-09, so null is
+			// accepted through anyOf rather than by a type next to $ref
+			property = &Type{AnyOf: []*Type{property, {Type: "null"}}}
+			nullable = false
+		}
+		if r.FormatByName != nil && property.Type == "string" && property.Format == "" {
+			property.Format = r.FormatByName(name)
+		}
 		property.structKeywordsFromTags(f)
+		if property.Ref != "" && (property.ReadOnly || property.WriteOnly) {
+			// siblings of $ref are ignored before draft 2019-09
+			ref := *property
+			ref.ReadOnly, ref.WriteOnly = false, false
+			property = &Type{AllOf: []*Type{&ref}, ReadOnly: property.ReadOnly, WriteOnly: property.WriteOnly}
+		}
+		if property.Description == "" {
+			property.Description = r.CommentMap[t.PkgPath()+"."+t.Name()+"."+f.Name]
+		}
+		if timeField && property.Description == "" {
+			property.Description = r.TimeDescription
+		}
+		r.reflectDeprecationNote(property, f)
+		if tuple, ok := r.tuples[r.genDefinitionName(t)+"."+name]; ok {
+			r.reflectTuple(property, tuple, name)
+		}
+		r.reflectAdditionalPropertiesSchema(property, definitions, f)
+		r.reflectExclusiveBounds(property, f)
+		if r.SortEnums {
+			sortEnum(property.Enum)
+		}
+		if r.AutoTitle && property.Title == "" {
+			property.Title = humanize(name)
+		}
+		if r.EnumLengthBounds {
+			enumLengthBounds(property)
+		}
+		if r.ExamplesFromEnum && len(property.Examples) == 0 && len(property.Enum) > 0 {
+			property.Examples = append([]interface{}(nil), property.Enum...)
+		}
+		if r.StrictTags {
+			validateStructTags(property, f)
+		}
+		if required && r.RequiredSlicesNonEmpty && property.Type == "array" && property.MinItems == nil {
+			property.MinItems = intPtr(1)
+		}
+		if nullable {
+			// the keywords of the tags constrain the non-null values
+			property = &Type{AnyOf: []*Type{property, {Type: "null"}}}
+		}
 		st.Properties[name] = property
 		if required {
 			st.Required = append(st.Required, name)
@@ -330,10 +1372,250 @@ func (r *Reflector) reflectStructFields(st *Type, definitions Definitions, t ref
 	}
 }
 
+func (r *Reflector) reflectTuple(t *Type, tuple *Type, name string) {
+	if r.Draft != Draft202012 {
+		panic("tuple schema of " + name + " requires Draft202012")
+	}
+	t.PrefixItems = tuple.PrefixItems
+	if tuple.Items != nil {
+		t.Items = tuple.Items
+	}
+}
+
+// reflectExclusiveBounds turns the boolean exclusiveMinimum and
+// exclusiveMaximum of the tags, which modify minimum and maximum as in
+// draft-04, into the numeric bounds of later drafts, and numeric ones back
+// for draft-04. A flag without a bound to modify is dropped, or panics
+// under StrictTags.
+func (r *Reflector) reflectExclusiveBounds(t *Type, f reflect.StructField) {
+	t.Minimum, t.ExclusiveMinimum = r.exclusiveBound(t.Minimum, t.ExclusiveMinimum, "exclusiveMinimum", f)
+	t.Maximum, t.ExclusiveMaximum = r.exclusiveBound(t.Maximum, t.ExclusiveMaximum, "exclusiveMaximum", f)
+}
+
+func (r *Reflector) exclusiveBound(bound json.Number, exclusive json.RawMessage, keyword string, f reflect.StructField) (json.Number, json.RawMessage) {
+	if len(exclusive) == 0 {
+		return bound, exclusive
+	}
+	if string(exclusive) != "true" {
+		if r.Draft != Draft04 {
+			return bound, exclusive
+		}
+		// draft-04 makes its bound exclusive to hold the numeric bound
+		if bound == "" {
+			return json.Number(exclusive), json.RawMessage("true")
+		}
+		boundKeyword := strings.ToLower(strings.TrimPrefix(keyword, "exclusive"))
+		if r.StrictTags {
+			for _, tag := range strings.Split(f.Tag.Get("jsonschema"), ",") {
+				if strings.HasPrefix(tag, boundKeyword+"=") {
+					panic(keyword + " of field " + f.Name + " conflicts with its " + boundKeyword + " in draft-04")
+				}
+			}
+		}
+		// otherwise the tighter of both bounds holds
+		b, _ := bound.Float64()
+		e, _ := strconv.ParseFloat(string(exclusive), 64)
+		if boundKeyword == "minimum" && e >= b || boundKeyword == "maximum" && e <= b {
+			return json.Number(exclusive), json.RawMessage("true")
+		}
+		return bound, nil
+	}
+	if bound == "" {
+		if r.StrictTags {
+			panic(keyword + " of field " + f.Name + " has no bound to make exclusive")
+		}
+		return bound, nil
+	}
+	if r.Draft == Draft04 {
+		return bound, exclusive
+	}
+	return "", json.RawMessage(bound)
+}
+
+// additionalPropertiesTag returns the additionalProperties of the tags of
+// f: true, false, a type such as `type:string` where alternatives are
+// separated by |, e.g. `type:string|number`, or the name of a type
+// registered with RegisterType. Names of other types are ignored, or panic
+// under StrictTags.
+func (r *Reflector) additionalPropertiesTag(definitions Definitions, f reflect.StructField) (json.RawMessage, bool) {
+	for _, tag := range strings.Split(f.Tag.Get("jsonschema"), ",") {
+		nameValue := strings.SplitN(tag, "=", 2)
+		if len(nameValue) != 2 || nameValue[0] != "additionalProperties" {
+			continue
+		}
+		if b, err := strconv.ParseBool(nameValue[1]); err == nil {
+			return json.RawMessage(strconv.FormatBool(b)), true
+		}
+
+		var valueSchema *Type
+		if types := strings.TrimPrefix(nameValue[1], "type:"); types != nameValue[1] {
+			valueSchema = &Type{}
+			for _, typ := range strings.Split(types, "|") {
+				valueSchema.AnyOf = append(valueSchema.AnyOf, &Type{Type: typ})
+			}
+			if len(valueSchema.AnyOf) == 1 {
+				valueSchema = valueSchema.AnyOf[0]
+			}
+		} else if registered, ok := r.types[nameValue[1]]; ok {
+			r.reflectTypeToSchema(definitions, registered)
+			valueSchema = &Type{Ref: r.definitionRef(r.definitionName(definitions, registered))}
+		} else {
+			if r.StrictTags {
+				panic("additionalProperties of field " + f.Name + " names unregistered type " + nameValue[1])
+			}
+			continue
+		}
+		additionalProperties, _ := json.Marshal(valueSchema)
+		return additionalProperties, true
+	}
+	return nil, false
+}
+
+// reflectAdditionalPropertiesSchema sets the additionalProperties of the
+// tags holding a schema for the values of an object, see
+// additionalPropertiesTag. Objects take true and false from objectKeywords.
+func (r *Reflector) reflectAdditionalPropertiesSchema(t *Type, definitions Definitions, f reflect.StructField) {
+	if t.Type != "object" {
+		return
+	}
+	additionalProperties, ok := r.additionalPropertiesTag(definitions, f)
+	if !ok || string(additionalProperties) == "true" || string(additionalProperties) == "false" {
+		return
+	}
+	t.AdditionalProperties = additionalProperties
+	// the catch-all pattern of maps would otherwise shadow it
+	delete(t.PatternProperties, ".*")
+	if len(t.PatternProperties) == 0 {
+		t.PatternProperties = nil
+	}
+}
+
+// reflectOverriddenStruct refers a struct field overriding its
+// additionalProperties by tag to a copy of the definition of its struct
+// with these additionalProperties, as the definition is shared with other
+// fields and siblings of $ref are ignored. The copy is named after the
+// definition, e.g. AddressOpen for true, AddressClosed for false and
+// AddressExtended for a schema.
+func (r *Reflector) reflectOverriddenStruct(t *Type, definitions Definitions, f reflect.StructField) *Type {
+	additionalProperties, ok := r.additionalPropertiesTag(definitions, f)
+	if !ok {
+		return t
+	}
+	st := f.Type
+	for st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	name := r.definitionName(definitions, st)
+	definition, ok := definitions[name]
+	if !ok || definition.Type != "object" {
+		return t
+	}
+	suffix := "Extended"
+	switch string(additionalProperties) {
+	case "true":
+		suffix = "Open"
+	case "false":
+		suffix = "Closed"
+	}
+	reopened := reopenedName(definitions, name, suffix, additionalProperties)
+	if definitions[reopened] == nil {
+		definitions[reopened] = &Type{reopens: name, AdditionalProperties: additionalProperties}
+	}
+	return &Type{Ref: r.definitionRef(reopened)}
+}
+
+// reopenedName returns the name of the copy of the definition with the
+// given name and additionalProperties, suffixed further when the name is
+// taken by another definition.
+func reopenedName(definitions Definitions, name, suffix string, additionalProperties json.RawMessage) string {
+	for i, candidate := 2, name+suffix; ; i++ {
+		definition, ok := definitions[candidate]
+		if !ok || definition.reopens == name && bytes.Equal(definition.AdditionalProperties, additionalProperties) {
+			return candidate
+		}
+		candidate = name + suffix + strconv.Itoa(i)
+	}
+}
+
+// reflectReopenedDefinitions copies the definitions reopened by
+// reflectOverriddenStruct once all of them are complete, which a struct
+// referring to itself is not while its fields are reflected.
+func (r *Reflector) reflectReopenedDefinitions(definitions Definitions) {
+	for name, definition := range definitions {
+		if definition.reopens == "" {
+			continue
+		}
+		reopened := *definitions[definition.reopens]
+		reopened.ID = r.definitionID(name)
+		reopened.goType = nil
+		reopened.reopens = definition.reopens
+		reopened.AdditionalProperties = definition.AdditionalProperties
+		reopened.Properties = make(map[string]*Type, len(reopened.Properties))
+		for property, schema := range definitions[definition.reopens].Properties {
+			reopened.Properties[property] = schema
+		}
+		reopened.Required = append([]string(nil), reopened.Required...)
+		*definition = reopened
+	}
+}
+
+// stringEncodedType returns the schema of a numeric or boolean field tagged
+// with the json `string` option, which encoding/json writes inside a JSON
+// string. It returns nil for any other field.
+func stringEncodedType(f reflect.StructField) *Type {
+	jsonTags, exist := f.Tag.Lookup("json")
+	if !exist || !hasTagOption(strings.Split(jsonTags, ","), "string") {
+		return nil
+	}
+
+	t := f.Type
+	if t.Name() == "" && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Type{Type: "string", Enum: []interface{}{"true", "false"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return &Type{Type: "string"}
+	}
+	return nil
+}
+
+// reflectDeprecationNote appends the deprecationNote of the tags, such as
+// the replacement of the field, to the description of a deprecated field.
+// A note on a field that is not deprecated is dropped, or panics under
+// StrictTags.
+func (r *Reflector) reflectDeprecationNote(t *Type, f reflect.StructField) {
+	var note string
+	for _, tag := range strings.Split(f.Tag.Get("jsonschema"), ",") {
+		if strings.HasPrefix(tag, "deprecationNote=") {
+			note = strings.TrimPrefix(tag, "deprecationNote=")
+		}
+	}
+	if note == "" {
+		return
+	}
+	if !t.Deprecated {
+		if r.StrictTags {
+			panic("deprecationNote of field " + f.Name + " is not deprecated")
+		}
+		return
+	}
+	if t.Description != "" {
+		t.Description += "\n\n"
+	}
+	t.Description += "Deprecated: " + note
+}
+
 func (t *Type) structKeywordsFromTags(f reflect.StructField) {
-	t.Description = f.Tag.Get("jsonschema_description")
-	t.extendJSONSchemaTags(&f)
 	tags := strings.Split(f.Tag.Get("jsonschema"), ",")
+	t.typeOverride(tags)
+	if description := f.Tag.Get("jsonschema_description"); description != "" {
+		t.Description = description
+	}
+	t.extendJSONSchemaTags(&f)
 	t.genericKeywords(tags)
 	switch t.Type {
 	case "string":
@@ -346,11 +1628,17 @@ func (t *Type) structKeywordsFromTags(f reflect.StructField) {
 		t.arrayKeywords(tags)
 	case "object":
 		t.objectKeywords(tags)
+	case "boolean":
+		t.booleanKeywords(tags)
 	}
 
 	t.attachCustomizedFormat(tags)
 }
 
+// extendJSONSchemaTags reads the enum and examples given as JSON arrays, for
+// values the comma-separated jsonschema tag cannot hold, such as strings
+// with commas. They come first, followed by those of the jsonschema tag
+// that are not duplicates.
 func (t *Type) extendJSONSchemaTags(f *reflect.StructField) {
 	if extendEnum := f.Tag.Get("jsonschema_enum"); len(extendEnum) > 0 {
 		var arr []interface{}
@@ -360,25 +1648,161 @@ func (t *Type) extendJSONSchemaTags(f *reflect.StructField) {
 			}
 		}
 	}
+	if extendExamples := f.Tag.Get("jsonschema_examples"); len(extendExamples) > 0 {
+		var arr []interface{}
+		if err := json.Unmarshal([]byte(extendExamples), &arr); err == nil {
+			for _, item := range arr {
+				t.Examples = appendUnique(t.Examples, item)
+			}
+		}
+	}
+}
+
+// validateStructTags panics on jsonschema tag keywords that were dropped
+// because they do not apply to the reflected type of the field.
+func validateStructTags(t *Type, f reflect.StructField) {
+	tags := strings.Split(f.Tag.Get("jsonschema"), ",")
+	for _, tag := range tags {
+		nameValue := strings.SplitN(tag, "=", 2)
+		if len(nameValue) == 2 && nameValue[0] == "format" && !formatApplies(nameValue[1], t.Type) {
+			panic("format " + nameValue[1] + " is not applicable to field " + f.Name + " of type " + f.Type.String())
+		}
+		if len(nameValue) == 2 && (nameValue[0] == "minimum" || nameValue[0] == "maximum") && (t.Type == "integer" || t.Type == "number") {
+			if _, ok := jsonNumber(nameValue[1]); !ok {
+				panic(nameValue[0] + " " + nameValue[1] + " of field " + f.Name + " is not a finite number")
+			}
+		}
+		if len(nameValue) == 2 && (nameValue[0] == "exclusiveMinimum" || nameValue[0] == "exclusiveMaximum") && (t.Type == "integer" || t.Type == "number") {
+			if _, err := strconv.ParseBool(nameValue[1]); err != nil {
+				if _, ok := jsonNumber(nameValue[1]); !ok {
+					panic(nameValue[0] + " " + nameValue[1] + " of field " + f.Name + " is not a finite number")
+				}
+			}
+		}
+		if len(nameValue) == 2 && (nameValue[0] == "exclusiveMinimum" || nameValue[0] == "exclusiveMaximum") && t.Type == "integer" {
+			if n, err := strconv.ParseFloat(nameValue[1], 64); err == nil && n != math.Trunc(n) {
+				panic(nameValue[0] + " " + nameValue[1] + " of integer field " + f.Name + " is not an integer")
+			}
+		}
+	}
+	for _, v := range t.Enum {
+		switch v.(type) {
+		case int, float64:
+			if !t.withinBounds(enumNumber(v)) {
+				panic(fmt.Sprintf("enum value %v of field %s is out of its bounds", v, f.Name))
+			}
+		}
+	}
+	if t.Default != nil && len(t.Enum) > 0 && len(appendUnique(t.Enum, t.Default)) > len(t.Enum) {
+		panic(fmt.Sprintf("default %v of field %s is not one of its enum values", t.Default, f.Name))
+	}
+}
+
+// withinBounds reports whether n satisfies the minimum, maximum and
+// exclusive bounds of t, in either their draft-04 or later form.
+func (t *Type) withinBounds(n float64) bool {
+	if min, err := t.Minimum.Float64(); err == nil {
+		if n < min || n == min && string(t.ExclusiveMinimum) == "true" {
+			return false
+		}
+	}
+	if max, err := t.Maximum.Float64(); err == nil {
+		if n > max || n == max && string(t.ExclusiveMaximum) == "true" {
+			return false
+		}
+	}
+	if min, err := strconv.ParseFloat(string(t.ExclusiveMinimum), 64); err == nil && n <= min {
+		return false
+	}
+	if max, err := strconv.ParseFloat(string(t.ExclusiveMaximum), 64); err == nil && n >= max {
+		return false
+	}
+	return true
 }
 
+// formatTypes lists the types known formats apply to, those of JSON Schema
+// and the numeric ones of OpenAPI. Unknown formats apply to strings only.
+var formatTypes = map[string][]string{
+	"date-time":             {"string"},
+	"date":                  {"string"},
+	"time":                  {"string"},
+	"duration":              {"string"},
+	"email":                 {"string"},
+	"idn-email":             {"string"},
+	"hostname":              {"string"},
+	"idn-hostname":          {"string"},
+	"ipv4":                  {"string"},
+	"ipv6":                  {"string"},
+	"uri":                   {"string"},
+	"uri-reference":         {"string"},
+	"iri":                   {"string"},
+	"iri-reference":         {"string"},
+	"uri-template":          {"string"},
+	"uuid":                  {"string"},
+	"json-pointer":          {"string"},
+	"relative-json-pointer": {"string"},
+	"regex":                 {"string"},
+	"byte":                  {"string"},
+	"binary":                {"string"},
+	"password":              {"string"},
+	"int32":                 {"integer"},
+	"int64":                 {"integer"},
+	"float":                 {"number"},
+	"double":                {"number"},
+}
+
+// formatApplies reports whether format applies to values of typ.
+func formatApplies(format, typ string) bool {
+	types, ok := formatTypes[format]
+	if !ok {
+		return typ == "string"
+	}
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// formats are dropped for types they do not apply to, see formatTypes
 func (t *Type) attachCustomizedFormat(tags []string) {
 	for _, tag := range tags {
-		nameValue := strings.Split(tag, "=")
+		nameValue := strings.SplitN(tag, "=", 2)
 		if len(nameValue) == 2 {
 			name, val := nameValue[0], nameValue[1]
 			if name == "format" {
-				t.Format = val
+				if formatApplies(val, t.Type) {
+					t.Format = val
+				}
 				return
 			}
 		}
 	}
 }
 
+// typeOverride replaces the reflected schema by a bare schema of the type
+// given by `jsonschema:"type=..."`, e.g. type=null for reserved fields.
+func (t *Type) typeOverride(tags []string) {
+	for _, tag := range tags {
+		nameValue := strings.SplitN(tag, "=", 2)
+		if len(nameValue) == 2 && nameValue[0] == "type" {
+			switch val := nameValue[1]; val {
+			case "null", "boolean", "object", "array", "number", "integer", "string":
+				*t = Type{Type: val}
+			}
+		}
+	}
+}
+
 // read struct tags for generic keyworks
 func (t *Type) genericKeywords(tags []string) {
 	for _, tag := range tags {
-		nameValue := strings.Split(tag, "=")
+		if tag == "deprecated" {
+			t.Deprecated = true
+			continue
+		}
+		nameValue := strings.SplitN(tag, "=", 2)
 		if len(nameValue) == 2 {
 			name, val := nameValue[0], nameValue[1]
 			switch name {
@@ -386,35 +1810,159 @@ func (t *Type) genericKeywords(tags []string) {
 				t.Title = val
 			case "description":
 				t.Description = val
+			case "deprecated":
+				b, _ := strconv.ParseBool(val)
+				t.Deprecated = b
+			case "readOnly":
+				b, _ := strconv.ParseBool(val)
+				t.ReadOnly = b
+			case "writeOnly":
+				b, _ := strconv.ParseBool(val)
+				t.WriteOnly = b
+			}
+		}
+	}
+}
+
+// sortEnum sorts enum values in place when they are all strings or all
+// numbers, leaving any other enum as is.
+func sortEnum(enum []interface{}) {
+	var strs, nums int
+	for _, v := range enum {
+		switch v.(type) {
+		case string:
+			strs++
+		case int, float64:
+			nums++
+		default:
+			return
+		}
+	}
+	switch len(enum) {
+	case strs:
+		sort.SliceStable(enum, func(i, j int) bool { return enum[i].(string) < enum[j].(string) })
+	case nums:
+		sort.SliceStable(enum, func(i, j int) bool { return enumNumber(enum[i]) < enumNumber(enum[j]) })
+	}
+}
+
+// humanize turns a snake_case, kebab-case or camelCase name into space
+// separated capitalized words, keeping acronyms, e.g. "User ID" for userID.
+func humanize(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			word[0] = unicode.ToUpper(word[0])
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	runes := []rune(name)
+	for i, c := range runes {
+		switch {
+		case c == '_' || c == '-' || c == '.' || unicode.IsSpace(c):
+			flush()
+			continue
+		case unicode.IsUpper(c) && len(word) > 0:
+			// a word starts at an upper case letter following a lower case
+			// letter or a digit, or ending an acronym
+			prev := runes[i-1]
+			if !unicode.IsUpper(prev) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				flush()
 			}
 		}
+		word = append(word, c)
+	}
+	flush()
+	return strings.Join(words, " ")
+}
+
+// enumLengthBounds sets the minLength and maxLength of t, unless set, to the
+// lengths of the shortest and longest of its enum values, if all strings.
+func enumLengthBounds(t *Type) {
+	if len(t.Enum) == 0 {
+		return
+	}
+	min, max := -1, 0
+	for _, v := range t.Enum {
+		str, ok := v.(string)
+		if !ok {
+			return
+		}
+		n := utf8.RuneCountInString(str)
+		if min < 0 || n < min {
+			min = n
+		}
+		if n > max {
+			max = n
+		}
+	}
+	if t.MinLength == nil {
+		t.MinLength = &min
+	}
+	if t.MaxLength == nil {
+		t.MaxLength = &max
+	}
+}
+
+func enumNumber(v interface{}) float64 {
+	if i, ok := v.(int); ok {
+		return float64(i)
 	}
+	return v.(float64)
+}
+
+func intPtr(i int) *int {
+	return &i
 }
 
+// appendUnique appends elem unless arr holds an equal value. Numbers are
+// equal by value, so that the float64 of a JSON tag equals the int of the
+// same number in a comma-separated tag.
 func appendUnique(arr []interface{}, elem interface{}) []interface{} {
 	for _, o := range arr {
-		if o == elem {
+		if reflect.DeepEqual(o, elem) || isNumber(o) && isNumber(elem) && enumNumber(o) == enumNumber(elem) {
 			return arr
 		}
 	}
 	return append(arr, elem)
 }
 
+func isNumber(v interface{}) bool {
+	switch v.(type) {
+	case int, float64:
+		return true
+	}
+	return false
+}
+
 // read struct tags for string type keyworks
 func (t *Type) stringKeywords(tags []string) {
 	for _, tag := range tags {
-		nameValue := strings.Split(tag, "=")
+		nameValue := strings.SplitN(tag, "=", 2)
 		if len(nameValue) == 2 {
 			name, val := nameValue[0], nameValue[1]
 			switch name {
 			case "minLength":
-				i, _ := strconv.Atoi(val)
-				t.MinLength = i
+				if i, err := strconv.Atoi(val); err == nil {
+					t.MinLength = &i
+				}
 			case "maxLength":
-				i, _ := strconv.Atoi(val)
-				t.MaxLength = i
+				if i, err := strconv.Atoi(val); err == nil {
+					t.MaxLength = &i
+				}
+			case "maxBytes":
+				// the length of at most maxBytes bytes encoded in base64
+				if i, err := strconv.Atoi(val); err == nil && t.Media != nil && t.Media.BinaryEncoding == "base64" {
+					t.MaxLength = intPtr((i + 2) / 3 * 4)
+				}
 			case "pattern":
 				t.Pattern = val
+			case "contentEncoding":
+				t.ContentEncoding = val
+			case "contentMediaType":
+				t.ContentMediaType = val
 			case "format":
 				switch val {
 				case "date-time", "email", "hostname", "ipv4", "ipv6", "uri":
@@ -435,7 +1983,7 @@ func (t *Type) stringKeywords(tags []string) {
 // read struct tags for numberic type keyworks
 func (t *Type) numbericKeywords(tags []string) {
 	for _, tag := range tags {
-		nameValue := strings.Split(tag, "=")
+		nameValue := strings.SplitN(tag, "=", 2)
 		if len(nameValue) == 2 {
 			name, val := nameValue[0], nameValue[1]
 			switch name {
@@ -443,17 +1991,27 @@ func (t *Type) numbericKeywords(tags []string) {
 				i, _ := strconv.Atoi(val)
 				t.MultipleOf = i
 			case "minimum":
-				i, _ := strconv.Atoi(val)
-				t.Minimum = i
+				if n, ok := jsonNumber(val); ok {
+					t.Minimum = n
+				}
 			case "maximum":
-				i, _ := strconv.Atoi(val)
-				t.Maximum = i
+				if n, ok := jsonNumber(val); ok {
+					t.Maximum = n
+				}
 			case "exclusiveMaximum":
-				b, _ := strconv.ParseBool(val)
-				t.ExclusiveMaximum = b
+				// numbers such as 0 and 1 are bounds rather than flags
+				if bound, ok := t.numericBound(val, name); ok {
+					t.ExclusiveMaximum = bound
+				} else if b, _ := strconv.ParseBool(val); b {
+					t.ExclusiveMaximum = []byte("true")
+				}
 			case "exclusiveMinimum":
-				b, _ := strconv.ParseBool(val)
-				t.ExclusiveMinimum = b
+				// numbers such as 0 and 1 are bounds rather than flags
+				if bound, ok := t.numericBound(val, name); ok {
+					t.ExclusiveMinimum = bound
+				} else if b, _ := strconv.ParseBool(val); b {
+					t.ExclusiveMinimum = []byte("true")
+				}
 			case "default":
 				i, _ := strconv.Atoi(val)
 				t.Default = i
@@ -470,19 +2028,89 @@ func (t *Type) numbericKeywords(tags []string) {
 	}
 }
 
+// numericBound parses the numeric value of an exclusive bound, written as
+// an integer for integers, such as Unix times, e.g. 1700000000 for 1.7e9.
+// Fractions are rounded away from the valid integers, which the bound
+// excludes alike, e.g. exclusiveMinimum=0.5 to 0; see validateStructTags.
+func (t *Type) numericBound(val, keyword string) (json.RawMessage, bool) {
+	n, ok := jsonNumber(val)
+	if !ok {
+		return nil, false
+	}
+	if t.Type != "integer" {
+		return json.RawMessage(n), true
+	}
+	f, _ := n.Float64()
+	if keyword == "exclusiveMinimum" {
+		f = math.Floor(f)
+	} else {
+		f = math.Ceil(f)
+	}
+	return json.RawMessage(strconv.FormatFloat(f, 'f', -1, 64)), true
+}
+
+// jsonNumber parses a number of the tags, such as a bound, into a JSON
+// number. Go forms JSON lacks, such as .5, +1 or 0x1p3, are normalized,
+// while JSON numbers are kept as written, not to round large integers.
+// Infinities and NaN are no numbers of JSON; see validateStructTags.
+func jsonNumber(val string) (json.Number, bool) {
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", false
+	}
+	if val[0] != '+' && val[0] != '.' && json.Valid([]byte(val)) {
+		return json.Number(val), true
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), true
+}
+
+// read struct tags for boolean type keyworks
+func (t *Type) booleanKeywords(tags []string) {
+	for _, tag := range tags {
+		nameValue := strings.SplitN(tag, "=", 2)
+		if len(nameValue) == 2 {
+			name, val := nameValue[0], nameValue[1]
+			switch name {
+			case "default":
+				if b, err := strconv.ParseBool(val); err == nil {
+					t.Default = b
+				}
+			case "enum":
+				if b, err := strconv.ParseBool(val); err == nil {
+					t.Enum = appendUnique(t.Enum, b)
+				}
+			}
+		}
+	}
+}
+
 // read struct tags for object type keyworks
 func (t *Type) objectKeywords(tags []string) {
 	for _, tag := range tags {
-		nameValue := strings.Split(tag, "=")
+		nameValue := strings.SplitN(tag, "=", 2)
 		if len(nameValue) == 2 {
 			name, val := nameValue[0], nameValue[1]
 			switch name {
 			case "additionalProperties":
-				t.AdditionalProperties = []byte(val)
-				break
-				// case "patternProperties":
-				// 	t.PatternProperties = val
-				// 	break
+				// type names are resolved by the Reflector, see RegisterType
+				if b, err := strconv.ParseBool(val); err == nil {
+					t.AdditionalProperties = []byte(strconv.FormatBool(b))
+				}
+			case "minProperties":
+				if i, err := strconv.Atoi(val); err == nil {
+					t.MinProperties = &i
+				}
+			case "maxProperties":
+				if i, err := strconv.Atoi(val); err == nil {
+					t.MaxProperties = &i
+				}
+			case "patternProperties":
+				// restricts the keys of maps to the pattern, which cannot
+				// hold commas
+				if values, ok := t.PatternProperties[".*"]; ok {
+					delete(t.PatternProperties, ".*")
+					t.PatternProperties[val] = values
+				}
 			}
 		}
 	}
@@ -492,18 +2120,25 @@ func (t *Type) objectKeywords(tags []string) {
 func (t *Type) arrayKeywords(tags []string) {
 	var defaultValues []interface{}
 	for _, tag := range tags {
-		nameValue := strings.Split(tag, "=")
+		if tag == "uniqueItems" {
+			t.UniqueItems = true
+			continue
+		}
+		nameValue := strings.SplitN(tag, "=", 2)
 		if len(nameValue) == 2 {
 			name, val := nameValue[0], nameValue[1]
 			switch name {
 			case "minItems":
-				i, _ := strconv.Atoi(val)
-				t.MinItems = i
+				if i, err := strconv.Atoi(val); err == nil {
+					t.MinItems = &i
+				}
 			case "maxItems":
-				i, _ := strconv.Atoi(val)
-				t.MaxItems = i
+				if i, err := strconv.Atoi(val); err == nil {
+					t.MaxItems = &i
+				}
 			case "uniqueItems":
-				t.UniqueItems = true
+				b, _ := strconv.ParseBool(val)
+				t.UniqueItems = b
 			case "default":
 				defaultValues = append(defaultValues, val)
 			}
@@ -514,17 +2149,25 @@ func (t *Type) arrayKeywords(tags []string) {
 	}
 }
 
+// hasTagOption reports whether the options following the name in a
+// comma-separated struct tag contain opt.
+func hasTagOption(tags []string, opt string) bool {
+	for _, tag := range tags[1:] {
+		if tag == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredFromJSONTags treats omitempty fields as optional, including those
+// encoding/json never omits, such as time.Time and other structs.
 func requiredFromJSONTags(tags []string) bool {
 	if ignoredByJSONTags(tags) {
 		return false
 	}
 
-	for _, tag := range tags[1:] {
-		if tag == "omitempty" {
-			return false
-		}
-	}
-	return true
+	return !hasTagOption(tags, "omitempty")
 }
 
 func requiredFromJSONSchemaTags(tags []string) bool {
@@ -540,6 +2183,21 @@ func requiredFromJSONSchemaTags(tags []string) bool {
 	return true
 }
 
+// explicitRequiredFromJSONSchemaTags reads the value form of the required
+// keyword, e.g. `jsonschema:"required=false"`. The bool reports whether
+// such a keyword was present.
+func explicitRequiredFromJSONSchemaTags(tags []string) (bool, bool) {
+	for _, tag := range tags {
+		nameValue := strings.SplitN(tag, "=", 2)
+		if len(nameValue) == 2 && nameValue[0] == "required" {
+			if b, err := strconv.ParseBool(nameValue[1]); err == nil {
+				return b, true
+			}
+		}
+	}
+	return false, false
+}
+
 func ignoredByJSONTags(tags []string) bool {
 	return tags[0] == "-"
 }
@@ -556,26 +2214,45 @@ func (r *Reflector) reflectFieldName(f reflect.StructField) (string, bool, bool)
 
 	jsonTagsList := strings.Split(jsonTags, ",")
 
+	// ignored fields are reported as tagged, so that ignored anonymous
+	// structs are not inlined either
 	if ignoredByJSONTags(jsonTagsList) {
-		return "", exist, false
+		return "", true, false
 	}
 
 	jsonSchemaTags := strings.Split(f.Tag.Get("jsonschema"), ",")
 	if ignoredByJSONSchemaTags(jsonSchemaTags) {
-		return "", exist, false
+		return "", true, false
 	}
 
 	name := f.Name
 	required := requiredFromJSONTags(jsonTagsList)
 
+	if r.StructOmitemptyIsRequired && f.Type.Kind() == reflect.Struct && !ignoredByJSONTags(jsonTagsList) {
+		required = true
+	}
+
 	if r.RequiredFromJSONSchemaTags {
 		required = requiredFromJSONSchemaTags(jsonSchemaTags)
 	}
 
+	// explicit `required=true|false` overrides whatever was inferred above
+	if explicit, ok := explicitRequiredFromJSONSchemaTags(jsonSchemaTags); ok {
+		required = explicit
+	}
+
 	if jsonTagsList[0] != "" {
 		name = jsonTagsList[0]
 	}
 
+	// `jsonschema:"name=..."` renames the property in the schema only
+	for _, tag := range jsonSchemaTags {
+		nameValue := strings.SplitN(tag, "=", 2)
+		if len(nameValue) == 2 && nameValue[0] == "name" && nameValue[1] != "" {
+			name = nameValue[1]
+		}
+	}
+
 	// field not anonymous and not export has no export name
 	if !f.Anonymous && f.PkgPath != "" {
 		name = ""
@@ -1,13 +1,18 @@
 package jsonschema
 
 import (
+	"context"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/url"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -96,6 +101,536 @@ type TestObject struct {
 	OtherTags2 map[string]string      `json:"otherTags2,omitempty" jsonschema:"omitempty,additionalProperties=true"`
 }
 
+type TestRequired struct {
+	Bare        string `json:"bare" jsonschema:"required"`
+	NotRequired string `json:"not_required" jsonschema:"required=false"`
+	Explicit    string `json:"explicit" jsonschema:"required=true"`
+}
+
+type TestContent struct {
+	Config string `json:"config" jsonschema:"contentMediaType=application/yaml"`
+	Binary string `json:"binary,omitempty" jsonschema:"contentEncoding=base64,contentMediaType=image/png"`
+}
+
+type TestOptionalDefault struct {
+	Host    string   `json:"host"`
+	Port    int      `json:"port,omitempty" jsonschema:"default=8080"`
+	Scheme  string   `json:"scheme,omitempty" jsonschema:"default=http"`
+	Methods []string `json:"methods,omitempty" jsonschema:"default=GET,default=POST"`
+}
+
+type TestStringEncoded struct {
+	Enabled  bool    `json:"enabled,string"`
+	Count    int64   `json:"count,string"`
+	Ratio    float64 `json:"ratio,omitempty,string"`
+	Optional *bool   `json:"optional,omitempty,string"`
+	Plain    bool    `json:"plain"`
+}
+
+type TestTimeSlice struct {
+	Timestamps []time.Time  `json:"timestamps"`
+	Optional   []*time.Time `json:"optional,omitempty"`
+	Window     [2]time.Time `json:"window"`
+}
+
+type TestMapOfStruct struct {
+	Home      Address            `json:"home"`
+	Addresses map[string]Address `json:"addresses"`
+}
+
+type TestIntegerRange struct {
+	Small int8   `json:"small"`
+	Port  uint16 `json:"port"`
+	Large int64  `json:"large"`
+	Huge  uint64 `json:"huge"`
+	Count int32  `json:"count" jsonschema:"minimum=1"`
+}
+
+type TestOneOfRequired struct {
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+func oneOfRequiredReflector() *Reflector {
+	r := &Reflector{}
+	r.AddOneOfRequired(TestOneOfRequired{}, []string{"email"}, []string{"phone", "country"})
+	return r
+}
+
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+
+var colorNames = [...]string{"red", "green", "blue"}
+
+func (c Color) String() string { return colorNames[c] }
+
+type TestStringerEnum struct {
+	Color    Color   `json:"color"`
+	Fallback *Color  `json:"fallback,omitempty"`
+	Palette  []Color `json:"palette,omitempty"`
+}
+
+func stringerEnumReflector() *Reflector {
+	r := &Reflector{}
+	r.RegisterStringerEnum(Color(0), Red, Green, Blue)
+	return r
+}
+
+type TestTypedAdditionalProperties struct {
+	Extra     interface{}            `json:"extra" jsonschema:"additionalProperties=Address"`
+	Locations map[string]interface{} `json:"locations" jsonschema:"additionalProperties=Address"`
+	Unknown   map[string]string      `json:"unknown" jsonschema:"additionalProperties=Unregistered"`
+	Office    *LegacyAddress         `json:"office" jsonschema:"additionalProperties=Address"`
+	Branch    LegacyAddress          `json:"branch" jsonschema:"additionalProperties=type:string|number"`
+}
+
+func typedAdditionalPropertiesReflector() *Reflector {
+	r := &Reflector{}
+	r.RegisterType(Address{})
+	return r
+}
+
+type LegacyAddress struct {
+	Line string `json:"line"`
+}
+
+type TestDeprecated struct {
+	Address Address        `json:"address"`
+	Legacy  *LegacyAddress `json:"legacy,omitempty"`
+	OldName string         `json:"old_name,omitempty" jsonschema:"deprecated=true"`
+}
+
+func deprecatedReflector() *Reflector {
+	r := &Reflector{}
+	r.DeprecateType(&LegacyAddress{})
+	return r
+}
+
+type TestEmbeddedMap struct {
+	MapType
+	Name string `json:"name"`
+}
+
+type TestTaggedEmbeddedMap struct {
+	MapType `json:"extras,omitempty"`
+	Name    string `json:"name"`
+}
+
+type TestErrorField struct {
+	Status string `json:"status"`
+	Err    error  `json:"error,omitempty"`
+}
+
+type TestExplicitZero struct {
+	Comment string            `json:"comment" jsonschema:"minLength=0,maxLength=140"`
+	Tags    []string          `json:"tags" jsonschema:"minItems=0,maxItems=10"`
+	Labels  map[string]string `json:"labels" jsonschema:"minProperties=0,maxProperties=5"`
+	Nothing [0]int            `json:"nothing"`
+}
+
+type TestTuple struct {
+	Event []interface{} `json:"event"`
+	Point []float64     `json:"point"`
+}
+
+func tupleReflector() *Reflector {
+	r := &Reflector{Draft: Draft202012}
+	r.AddTupleSchema("TestTuple.event", []*Type{
+		{Type: "string", Format: "date-time"},
+		{Type: "string", Enum: []interface{}{"debug", "info", "error"}},
+	}, &Type{Type: "string"})
+	r.AddTupleSchema("TestTuple.point", []*Type{{Type: "number"}, {Type: "number"}}, nil)
+	return r
+}
+
+type TestYAMLTags struct {
+	Name     string `yaml:"name"`
+	Nickname string `yaml:"nickname,omitempty"`
+	Ignored  string `yaml:"-"`
+	Both     string `json:"both" yaml:"both_yaml,omitempty"`
+}
+
+type TestTimeDescription struct {
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt *time.Time  `json:"updated_at,omitempty"`
+	History   []time.Time `json:"history,omitempty"`
+	ExpiresAt time.Time   `json:"expires_at" jsonschema:"description=when the token expires"`
+}
+
+type TestExamples struct {
+	Name   string      `json:"name" jsonschema_examples:"[\"joe\",\"lucy, the second\"]"`
+	Age    int         `json:"age" jsonschema:"example=21" jsonschema_examples:"[18,30,65]"`
+	Config interface{} `json:"config" jsonschema_examples:"[{\"debug\":true},{\"debug\":true},{},null]"`
+}
+
+type TestOptionalPointer struct {
+	Count *int     `json:"count,omitempty" jsonschema:"minimum=0"`
+	Ratio *float64 `json:"ratio,omitempty"`
+	Total *int     `json:"total"`
+}
+
+type TestRequiredSlices struct {
+	Members  []string `json:"members"`
+	Owners   []string `json:"owners" jsonschema:"minItems=2"`
+	Watchers []string `json:"watchers,omitempty"`
+	Avatar   []byte   `json:"avatar"`
+}
+
+type TestCompositeTitles struct {
+	Members []Address         `json:"members" jsonschema:"title=Members,description=people in the group"`
+	Labels  map[string]string `json:"labels" jsonschema:"title=Labels,description=free form labels"`
+}
+
+type TestEmptyStruct struct {
+	Marker struct{} `json:"marker"`
+	Inline struct {
+		Enabled bool `json:"enabled"`
+	} `json:"inline"`
+}
+
+type TestSets struct {
+	IDs   map[int]struct{}    `json:"ids"`
+	Names map[string]struct{} `json:"names"`
+	Flags map[string]bool     `json:"flags"`
+}
+
+type TestVariant struct {
+	Kind       string `json:"kind" jsonschema:"enum=card,enum=bank"`
+	CardNumber string `json:"card_number,omitempty"`
+	Expiry     string `json:"expiry,omitempty"`
+	IBAN       string `json:"iban,omitempty"`
+}
+
+func variantReflector() *Reflector {
+	r := &Reflector{Draft: Draft07}
+	r.AddVariant(TestVariant{}, "kind", "card", []string{"card_number", "expiry"})
+	r.AddVariant(TestVariant{}, "kind", "bank", []string{"iban"})
+	return r
+}
+
+type Audit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type Revision struct {
+	Rev int `json:"rev"`
+}
+
+type Internal struct {
+	Secret string `json:"secret"`
+}
+
+type TestSkippedEmbedded struct {
+	Audit    `json:"-"`
+	Revision `jsonschema:"-"`
+	Internal `yaml:"-"`
+	Address
+	Name string `json:"name"`
+}
+
+type TestConstrainedMap struct {
+	Labels   map[string]interface{} `json:"labels" jsonschema:"additionalProperties=type:string"`
+	Metadata map[string]interface{} `json:"metadata" jsonschema:"additionalProperties=type:string|number"`
+}
+
+type TestEqualsInValue struct {
+	Query string `json:"query" jsonschema:"pattern=^[a-z]+=[0-9]+$,description=key=value pair,default=a=1"`
+}
+
+type Release struct {
+	Major, Minor int
+}
+
+func (v Release) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor))
+}
+
+func (Release) JSONSchemaType() string { return "string" }
+
+type Counter struct {
+	n int
+}
+
+func (c *Counter) MarshalJSON() ([]byte, error) { return json.Marshal(c.n) }
+
+func (*Counter) JSONSchemaType() string { return "integer" }
+
+type Versioned interface {
+	json.Marshaler
+	JSONSchemaType() string
+}
+
+type TestSchemaTyper struct {
+	Version  Release    `json:"version"`
+	Previous *Release   `json:"previous,omitempty"`
+	Hits     Counter    `json:"hits"`
+	History  []*Counter `json:"history"`
+	Current  Versioned  `json:"current"`
+}
+
+type TestUnserializable struct {
+	Name     string          `json:"name"`
+	Context  context.Context `json:"context"`
+	Lock     *sync.Mutex     `json:"lock"`
+	Body     io.ReadCloser   `json:"body"`
+	Done     chan struct{}   `json:"done"`
+	Callback func() error    `json:"callback"`
+}
+
+type TestBooleanEnum struct {
+	Accepted bool `json:"accepted" jsonschema:"enum=true"`
+	Verified bool `json:"verified" jsonschema:"enum=true,enum=false,default=false"`
+}
+
+type TestEnumExamples struct {
+	Status   string `json:"status" jsonschema:"enum=active,enum=suspended"`
+	Priority int    `json:"priority" jsonschema:"enum=1,enum=2,enum=3,example=2"`
+	Color    Color  `json:"color"`
+	Name     string `json:"name"`
+}
+
+type TestDateFormat struct {
+	Birthday    time.Time  `json:"birthday" jsonschema:"format=date"`
+	Anniversary *time.Time `json:"anniversary,omitempty" jsonschema:"format=date"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type TestJSONNumber struct {
+	Score    json.Number  `json:"score" jsonschema:"minimum=0,maximum=100"`
+	Discount *json.Number `json:"discount,omitempty" jsonschema:"maximum=0.5"`
+}
+
+type TestSchemaName struct {
+	DisplayName string `json:"dn" jsonschema:"name=displayName,required"`
+	Email       string `jsonschema:"name=email,format=email"`
+	Age         int    `json:"age,omitempty" jsonschema:"name=,minimum=0"`
+}
+
+type TestNullType struct {
+	Name     string      `json:"name"`
+	Reserved interface{} `json:"reserved" jsonschema:"type=null,description=Reserved for future use"`
+	Code     int         `json:"code" jsonschema:"type=string,maxLength=8"`
+}
+
+type ShadowedBase struct {
+	ID    string `json:"id" jsonschema:"format=uuid"`
+	Name  string `json:"name,omitempty" jsonschema:"minLength=1"`
+	Notes string `json:"notes"`
+}
+
+type TestShadowedFields struct {
+	ID int `json:"id" jsonschema:"minimum=1"`
+	ShadowedBase
+	Name string `json:"name" jsonschema:"maxLength=10"`
+}
+
+type TestOmitEmptyTime struct {
+	Deadline  time.Time  `json:"deadline"`
+	CreatedAt time.Time  `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type TestMapOfSlices struct {
+	Branches map[string][]Address  `json:"branches"`
+	Archived map[string][]*Address `json:"archived,omitempty"`
+}
+
+type Credentials struct {
+	Password string `json:"password"`
+}
+
+type TestReadWriteOnly struct {
+	ID          string       `json:"id" jsonschema:"readOnly=true"`
+	Audit       Audit        `json:"audit" jsonschema:"readOnly=true"`
+	Metadata    struct{}     `json:"metadata" jsonschema:"readOnly=true"`
+	Credentials *Credentials `json:"credentials,omitempty" jsonschema:"writeOnly=true"`
+}
+
+type TestAnyOf struct {
+	Target interface{} `json:"target" jsonschema:"description=Host name or address"`
+	Port   interface{} `json:"port,omitempty"`
+}
+
+func anyOfReflector() *Reflector {
+	r := &Reflector{}
+	r.AddAnyOf(TestAnyOf{}, "target", &Type{Type: "string", Format: "hostname"}, &Type{Type: "string", Format: "ipv4"})
+	r.AddAnyOf(&TestAnyOf{}, "port", &Type{Type: "integer"})
+	r.AddAnyOf(&TestAnyOf{}, "port", &Type{Type: "string", Pattern: "^[0-9]+$"})
+	return r
+}
+
+type TestMatrix struct {
+	Cells   [][]int       `json:"cells"`
+	Layers  [][][]float64 `json:"layers,omitempty"`
+	Borders [2][]string   `json:"borders,omitempty"`
+}
+
+type TestStructOmitempty struct {
+	Audit    Audit     `json:"audit,omitempty"`
+	Revision *Revision `json:"revision,omitempty"`
+	Updated  time.Time `json:"updated,omitempty"`
+	Note     string    `json:"note,omitempty"`
+}
+
+type TestByteSizes struct {
+	Key       []byte   `json:"key" jsonschema:"maxBytes=16"`
+	Signature []byte   `json:"signature" jsonschema:"maxBytes=64"`
+	Digest    [32]byte `json:"digest"`
+	Name      string   `json:"name" jsonschema:"maxBytes=16"`
+}
+
+type TestBareDeprecated struct {
+	Nickname string `json:"nickname,omitempty" jsonschema:"deprecated,description=Use name instead"`
+	Name     string `json:"name"`
+	Alias    string `json:"alias,omitempty" jsonschema:"deprecated=false"`
+}
+
+type TestNullableRefs struct {
+	Home    Address  `json:"home"`
+	Billing *Address `json:"billing,omitempty" jsonschema:"description=Defaults to home"`
+	Count   *int     `json:"count,omitempty"`
+}
+
+type TestRawMessage struct {
+	Kind    string          `json:"kind"`
+	Payload json.RawMessage `json:"payload" jsonschema:"description=Event payload"`
+	Extra   json.RawMessage `json:"extra,omitempty"`
+}
+
+func rawMessageReflector() *Reflector {
+	r := &Reflector{}
+	r.AddFieldSchema(TestRawMessage{}, "payload", &Type{
+		Type:       "object",
+		Properties: map[string]*Type{"id": {Type: "string"}},
+		Required:   []string{"id"},
+	})
+	return r
+}
+
+type TestMapOfTime struct {
+	Milestones map[string]time.Time  `json:"milestones"`
+	Reminders  map[string]*time.Time `json:"reminders,omitempty"`
+}
+
+type TestCombinedEnums struct {
+	Label string `json:"label" jsonschema:"enum=plain,enum=other" jsonschema_enum:"[\"a, b\",\"plain\"]"`
+	Level int    `json:"level" jsonschema:"enum=2,enum=3" jsonschema_enum:"[1,2]"`
+}
+
+type TestFloatPrecision struct {
+	Ratio    float32   `json:"ratio"`
+	Weight   float64   `json:"weight"`
+	Scores   []float32 `json:"scores"`
+	Discount *float32  `json:"discount,omitempty" jsonschema:"maximum=1"`
+}
+
+type TestEnumLengths struct {
+	Status   string `json:"status" jsonschema:"enum=on,enum=off,enum=standby"`
+	Currency string `json:"currency" jsonschema:"enum=€,enum=EUR,minLength=1"`
+	Level    int    `json:"level" jsonschema:"enum=1,enum=20"`
+	Name     string `json:"name"`
+}
+
+type LangCode string
+
+type Slug string
+
+type TestNamedKeys struct {
+	Titles map[LangCode]string `json:"titles"`
+	Pages  map[Slug]int        `json:"pages"`
+}
+
+func namedKeysReflector() *Reflector {
+	return &Reflector{TypeMapper: func(t reflect.Type) *Type {
+		if t == reflect.TypeOf(LangCode("")) {
+			return &Type{Type: "string", Pattern: "^[a-z]{2}$"}
+		}
+		return nil
+	}}
+}
+
+type TestUniqueItems struct {
+	Tags    []string `json:"tags" jsonschema:"uniqueItems"`
+	Ports   []int    `json:"ports" jsonschema:"minItems=1,uniqueItems=true"`
+	History []string `json:"history" jsonschema:"uniqueItems=false"`
+}
+
+type TestReopenedStruct struct {
+	Home     Address  `json:"home"`
+	Labels   *Address `json:"labels,omitempty" jsonschema:"additionalProperties=true"`
+	Settings struct {
+		Theme string `json:"theme"`
+	} `json:"settings" jsonschema:"additionalProperties=true"`
+}
+
+type TestDurations struct {
+	Timeout time.Duration  `json:"timeout"`
+	Retry   *time.Duration `json:"retry,omitempty" jsonschema:"description=Delay between attempts"`
+	Limit   *int           `json:"limit,omitempty" jsonschema:"minimum=1"`
+}
+
+type TestSingleExample struct {
+	Name  string `json:"name" jsonschema:"example=joe"`
+	Age   int    `json:"age" jsonschema:"example=21"`
+	Email string `json:"email" jsonschema_examples:"[\"joe@example.com\"]"`
+}
+
+type TestConflictingRequired struct {
+	Nickname string `json:"nickname,omitempty" jsonschema:"required"`
+	Email    string `json:"email,omitempty" jsonschema:"required=true"`
+	Phone    string `json:"phone" jsonschema:"omitempty"`
+	Fax      string `json:"fax" jsonschema:"required=false"`
+}
+
+type TestStrictPatternMap struct {
+	Labels   map[string]string `json:"labels" jsonschema:"patternProperties=^[a-z][a-z0-9_]*$,additionalProperties=false"`
+	Counters map[string]int    `json:"counters" jsonschema:"additionalProperties=false,patternProperties=^x-"`
+}
+
+type TestWellKnownRefs struct {
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt *time.Time  `json:"updated_at,omitempty"`
+	History   []time.Time `json:"history"`
+	Audit     Audit       `json:"audit"`
+}
+
+type TestUnixTimeBounds struct {
+	CreatedAt int64   `json:"created_at" jsonschema:"exclusiveMinimum=1.6e9,exclusiveMaximum=4102444800"`
+	ExpiresAt int64   `json:"expires_at" jsonschema:"exclusiveMinimum=1600000000.5,exclusiveMaximum=4102444800.5"`
+	UpdatedAt int64   `json:"updated_at" jsonschema:"minimum=1600000000,exclusiveMinimum=1700000000,maximum=4102444800,exclusiveMaximum=4102444801"`
+	Epoch     int64   `json:"epoch" jsonschema:"exclusiveMinimum=0"`
+	Ratio     float64 `json:"ratio" jsonschema:"exclusiveMinimum=.5,exclusiveMaximum=+1e3"`
+	Infinite  float64 `json:"infinite" jsonschema:"exclusiveMinimum=-Inf,exclusiveMaximum=NaN"`
+	Forever   int64   `json:"forever" jsonschema:"exclusiveMaximum=Inf"`
+	Seconds   float64 `json:"seconds" jsonschema:"exclusiveMinimum=0.5"`
+}
+
+type TestDeprecationNote struct {
+	Host    string `json:"host" jsonschema:"deprecated=true,deprecationNote=use address instead"`
+	Port    int    `json:"port" jsonschema:"deprecated,description=the port to listen on,deprecationNote=use address instead"`
+	Address string `json:"address"`
+	Timeout int    `json:"timeout" jsonschema:"deprecationNote=not deprecated yet"`
+}
+
+type TestNumberForms struct {
+	Ratio    float64 `json:"ratio" jsonschema:"minimum=.5,maximum=+1e3"`
+	Size     int     `json:"size" jsonschema:"minimum=0x1p3,maximum=18446744073709551615"`
+	Infinite float64 `json:"infinite" jsonschema:"minimum=-Inf,maximum=NaN"`
+}
+
+func examplesFromEnumReflector() *Reflector {
+	r := stringerEnumReflector()
+	r.ExamplesFromEnum = true
+	return r
+}
+
 func TestSchemaGeneration(t *testing.T) {
 	tests := []struct {
 		typ       interface{}
@@ -121,6 +656,83 @@ func TestSchemaGeneration(t *testing.T) {
 		{&TestEnum{}, &Reflector{RequiredFromJSONSchemaTags: true}, "fixtures/enum.json"},
 		{&TestEnum{}, &Reflector{RequiredFromJSONSchemaTags: true, DefinitionNameWithPackage: true}, "fixtures/enum_definition_with_package.json"},
 		{&TestObject{}, &Reflector{RequiredFromJSONSchemaTags: true}, "fixtures/map_object.json"},
+		{&TestRequired{}, &Reflector{RequiredFromJSONSchemaTags: true}, "fixtures/required_explicit.json"},
+		{&TestContent{}, &Reflector{}, "fixtures/content_media_type.json"},
+		{&TestOptionalDefault{}, &Reflector{}, "fixtures/optional_default.json"},
+		{&TestStringEncoded{}, &Reflector{}, "fixtures/string_encoded.json"},
+		{&TestTimeSlice{}, &Reflector{}, "fixtures/time_slice.json"},
+		{&TestMapOfStruct{}, &Reflector{}, "fixtures/map_of_struct.json"},
+		{&TestIntegerRange{}, &Reflector{IntegerRangeBounds: true}, "fixtures/integer_range.json"},
+		{&TestOneOfRequired{}, oneOfRequiredReflector(), "fixtures/one_of_required.json"},
+		{&TestStringerEnum{}, stringerEnumReflector(), "fixtures/stringer_enum.json"},
+		{&TestTypedAdditionalProperties{}, typedAdditionalPropertiesReflector(), "fixtures/additional_properties_type.json"},
+		{&TestDeprecated{}, deprecatedReflector(), "fixtures/deprecated.json"},
+		{&TestEmbeddedMap{}, &Reflector{}, "fixtures/embedded_map.json"},
+		{&TestTaggedEmbeddedMap{}, &Reflector{}, "fixtures/embedded_map_tagged.json"},
+		{&TestErrorField{}, &Reflector{}, "fixtures/error_field.json"},
+		{&TestErrorField{}, &Reflector{SkipErrorFields: true}, "fixtures/error_field_skipped.json"},
+		{&TestExplicitZero{}, &Reflector{}, "fixtures/explicit_zero.json"},
+		{&TestTuple{}, tupleReflector(), "fixtures/tuple.json"},
+		{&TestYAMLTags{}, &Reflector{}, "fixtures/yaml_tags.json"},
+		{&TestTimeDescription{}, &Reflector{TimeDescription: "RFC 3339 timestamp"}, "fixtures/time_description.json"},
+		{&TestExamples{}, &Reflector{}, "fixtures/examples.json"},
+		{&TestExamples{}, &Reflector{OpenAPI30: true}, "fixtures/examples_openapi30.json"},
+		{&TestOptionalPointer{}, &Reflector{}, "fixtures/optional_pointer.json"},
+		{&TestRequiredSlices{}, &Reflector{RequiredSlicesNonEmpty: true}, "fixtures/required_slices_non_empty.json"},
+		{&TestCompositeTitles{}, &Reflector{}, "fixtures/composite_titles.json"},
+		{&TestEmptyStruct{}, &Reflector{}, "fixtures/empty_struct.json"},
+		{&TestEmptyStruct{}, &Reflector{AllowAdditionalProperties: true}, "fixtures/empty_struct_allow_additional_props.json"},
+		{&TestSets{}, &Reflector{SetAsArray: true}, "fixtures/set_as_array.json"},
+		{&TestVariant{}, variantReflector(), "fixtures/variant.json"},
+		{&TestSkippedEmbedded{}, &Reflector{}, "fixtures/skipped_embedded.json"},
+		{&TestConstrainedMap{}, &Reflector{}, "fixtures/constrained_map.json"},
+		{&TestEqualsInValue{}, &Reflector{}, "fixtures/equals_in_value.json"},
+		{&TestSchemaTyper{}, &Reflector{}, "fixtures/schema_typer.json"},
+		{&TestUnserializable{}, &Reflector{}, "fixtures/unserializable.json"},
+		{&TestBooleanEnum{}, &Reflector{}, "fixtures/boolean_enum.json"},
+		{&TestEnumExamples{}, examplesFromEnumReflector(), "fixtures/examples_from_enum.json"},
+		{&TestDateFormat{}, &Reflector{}, "fixtures/date_format.json"},
+		{&TestJSONNumber{}, &Reflector{}, "fixtures/json_number.json"},
+		{&TestSchemaName{}, &Reflector{}, "fixtures/schema_name.json"},
+		{&TestNullType{}, &Reflector{}, "fixtures/null_type.json"},
+		{&TestShadowedFields{}, &Reflector{}, "fixtures/shadowed_fields.json"},
+		{&TestOmitEmptyTime{}, &Reflector{}, "fixtures/omitempty_time.json"},
+		{&TestMapOfSlices{}, &Reflector{}, "fixtures/map_of_slices.json"},
+		{&TestReadWriteOnly{}, &Reflector{}, "fixtures/read_write_only.json"},
+		{&TestAnyOf{}, anyOfReflector(), "fixtures/any_of.json"},
+		{&TestMatrix{}, &Reflector{}, "fixtures/matrix.json"},
+		{&TestStructOmitempty{}, &Reflector{}, "fixtures/struct_omitempty.json"},
+		{&TestStructOmitempty{}, &Reflector{StructOmitemptyIsRequired: true}, "fixtures/struct_omitempty_required.json"},
+		{&TestByteSizes{}, &Reflector{}, "fixtures/byte_sizes.json"},
+		{&TestBareDeprecated{}, &Reflector{}, "fixtures/bare_deprecated.json"},
+		{&TestNullableRefs{}, &Reflector{Nullable: true}, "fixtures/nullable_refs.json"},
+		{&TestRawMessage{}, rawMessageReflector(), "fixtures/raw_message.json"},
+		{&Address{}, &Reflector{ExpandedStruct: true, AdditionalProperties: AdditionalPropertiesFalse}, "fixtures/additional_properties_false.json"},
+		{&Address{}, &Reflector{ExpandedStruct: true, AdditionalProperties: AdditionalPropertiesTrue}, "fixtures/additional_properties_true.json"},
+		{&Address{}, &Reflector{ExpandedStruct: true, AdditionalProperties: AdditionalPropertiesOmitted}, "fixtures/additional_properties_omitted.json"},
+		{&TestMapOfTime{}, &Reflector{}, "fixtures/map_of_time.json"},
+		{&TestCombinedEnums{}, &Reflector{}, "fixtures/combined_enums.json"},
+		{&TestFloatPrecision{}, &Reflector{}, "fixtures/float_precision.json"},
+		{&TestFloatPrecision{}, &Reflector{NumberFormats: true}, "fixtures/float_precision_formats.json"},
+		{&TestEnumLengths{}, &Reflector{EnumLengthBounds: true}, "fixtures/enum_lengths.json"},
+		{[]Address{}, &Reflector{}, "fixtures/root_slice.json"},
+		{&TestNamedKeys{}, namedKeysReflector(), "fixtures/named_keys.json"},
+		{&TestUniqueItems{}, &Reflector{}, "fixtures/unique_items.json"},
+		{&TestUser{}, &Reflector{AutoTitle: true}, "fixtures/auto_title.json"},
+		{&TestReopenedStruct{}, &Reflector{}, "fixtures/reopened_struct.json"},
+		{&TestDurations{}, &Reflector{}, "fixtures/durations.json"},
+		{&TestDurations{}, &Reflector{DurationAsString: true, Nullable: true}, "fixtures/durations_nullable_strings.json"},
+		{&TestSingleExample{}, &Reflector{Draft: Draft07}, "fixtures/single_example_draft07.json"},
+		{&TestConflictingRequired{}, &Reflector{}, "fixtures/conflicting_required.json"},
+		{&TestConflictingRequired{}, &Reflector{RequiredFromJSONSchemaTags: true}, "fixtures/conflicting_required_from_jsonschema_tags.json"},
+		{&TestStrictPatternMap{}, &Reflector{}, "fixtures/strict_pattern_map.json"},
+		{&TestWellKnownRefs{}, &Reflector{WellKnownRefs: true}, "fixtures/well_known_refs.json"},
+		{&TestUnixTimeBounds{}, &Reflector{Draft: Draft07}, "fixtures/unix_time_bounds.json"},
+		{&TestUnixTimeBounds{}, &Reflector{}, "fixtures/unix_time_bounds_draft04.json"},
+		{&TestDeprecationNote{}, &Reflector{}, "fixtures/deprecation_note.json"},
+		{&TestNumberForms{}, &Reflector{}, "fixtures/number_forms.json"},
+		{&TestMapOfStruct{}, &Reflector{RefFormat: URN, RefBase: "urn:example"}, "fixtures/ref_format_urn.json"},
+		{&TestMapOfStruct{}, &Reflector{RefFormat: AbsoluteURL, RefBase: "https://example.com/schemas/"}, "fixtures/ref_format_url.json"},
 	}
 
 	for _, tt := range tests {
@@ -141,3 +753,844 @@ func TestSchemaGeneration(t *testing.T) {
 		})
 	}
 }
+
+// Address is a postal address.
+type Address struct {
+	// Street holds the street and house number.
+	Street string `json:"street"`
+	City   string `json:"city"` // the city or town
+}
+
+// manyAddressesType is a struct with 50 fields all of type Address.
+func manyAddressesType() reflect.Type {
+	fields := make([]reflect.StructField, 50)
+	for i := range fields {
+		fields[i] = reflect.StructField{
+			Name: "Address" + strconv.Itoa(i),
+			Type: reflect.TypeOf(Address{}),
+		}
+	}
+	return reflect.StructOf(fields)
+}
+
+func TestRepeatedTypeReflectedOnce(t *testing.T) {
+	r := &Reflector{ExpandedStruct: true}
+	s := r.ReflectFromType(manyAddressesType())
+
+	require.Len(t, s.Definitions, 1)
+	require.Contains(t, s.Definitions, "Address")
+	require.Len(t, s.Properties, 50)
+	for _, property := range s.Properties {
+		require.Equal(t, "#/definitions/Address", property.Ref)
+	}
+}
+
+func BenchmarkReflectRepeatedType(b *testing.B) {
+	typ := manyAddressesType()
+	r := &Reflector{ExpandedStruct: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r.ReflectFromType(typ)
+	}
+}
+
+func TestMetaSchema(t *testing.T) {
+	r := &Reflector{Draft: Draft202012, FormatAssertion: true, MetaSchemaID: "https://example.com/meta"}
+	s := r.Reflect(&TestUser{})
+	require.Equal(t, "https://example.com/meta", s.Version)
+	require.Nil(t, s.Vocabulary)
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"$defs"`)
+
+	meta := r.MetaSchema()
+	require.Equal(t, "https://json-schema.org/draft/2020-12/schema", meta.Version)
+	require.Equal(t, "https://example.com/meta", meta.ID)
+	require.Equal(t, "meta", meta.DynamicAnchor)
+	require.Len(t, meta.AllOf, 7)
+	require.Equal(t, "https://json-schema.org/draft/2020-12/meta/core", meta.AllOf[0].Ref)
+	require.Equal(t, map[string]bool{
+		"https://json-schema.org/draft/2020-12/vocab/core":             true,
+		"https://json-schema.org/draft/2020-12/vocab/applicator":       true,
+		"https://json-schema.org/draft/2020-12/vocab/unevaluated":      true,
+		"https://json-schema.org/draft/2020-12/vocab/validation":       true,
+		"https://json-schema.org/draft/2020-12/vocab/meta-data":        true,
+		"https://json-schema.org/draft/2020-12/vocab/content":          true,
+		"https://json-schema.org/draft/2020-12/vocab/format-assertion": true,
+	}, meta.Vocabulary)
+
+	r.FormatAssertion = false
+	require.True(t, r.MetaSchema().Vocabulary["https://json-schema.org/draft/2020-12/vocab/format-annotation"])
+
+	r = &Reflector{Draft: Draft201909, FormatAssertion: true, MetaSchemaID: "https://example.com/meta"}
+	meta = r.MetaSchema()
+	require.True(t, meta.RecursiveAnchor)
+	require.True(t, meta.Vocabulary["https://json-schema.org/draft/2019-09/vocab/format"])
+	require.True(t, meta.Vocabulary["https://json-schema.org/draft/2019-09/vocab/applicator"])
+
+	r.FormatAssertion = false
+	require.False(t, r.MetaSchema().Vocabulary["https://json-schema.org/draft/2019-09/vocab/format"])
+
+	// drafts before 2019-09 have no vocabularies
+	r = &Reflector{Draft: Draft07, FormatAssertion: true, MetaSchemaID: "https://example.com/meta"}
+	require.Nil(t, r.MetaSchema())
+	require.Equal(t, "http://json-schema.org/draft-07/schema#", r.Reflect(&TestUser{}).Version)
+}
+
+func TestRootExample(t *testing.T) {
+	example := &TestUser{ID: 1, Name: "joe", Friends: []int{2, 3}}
+	r := &Reflector{ExpandedStruct: true, RootExample: example}
+	s := r.Reflect(&TestUser{})
+	require.Len(t, s.Examples, 1)
+
+	expectedJSON, _ := json.Marshal(example)
+	actualJSON, err := json.Marshal(s.Examples[0])
+	require.NoError(t, err)
+	require.JSONEq(t, string(expectedJSON), string(actualJSON))
+
+	require.Panics(t, func() {
+		(&Reflector{RootExample: func() {}}).Reflect(&TestUser{})
+	})
+}
+
+type TestNumericFormat struct {
+	Count int `json:"count" jsonschema:"format=email"`
+}
+
+func TestFormatOnNonString(t *testing.T) {
+	s := (&Reflector{ExpandedStruct: true}).Reflect(&TestNumericFormat{})
+	require.Equal(t, "integer", s.Properties["count"].Type)
+	require.Empty(t, s.Properties["count"].Format)
+
+	require.PanicsWithValue(t, "format email is not applicable to field Count of type int", func() {
+		(&Reflector{StrictTags: true}).Reflect(&TestNumericFormat{})
+	})
+	require.NotPanics(t, func() {
+		(&Reflector{StrictTags: true}).Reflect(&TestUser{})
+	})
+}
+
+func TestRegisterStringerEnumRequiresStringer(t *testing.T) {
+	require.PanicsWithValue(t, "enum value 1 of type int does not implement fmt.Stringer", func() {
+		(&Reflector{}).RegisterStringerEnum(0, 1)
+	})
+}
+
+type TestJSONTagOptions struct {
+	A int `json:"a,omitempty,string"`
+	B int `json:"b,string,omitempty"`
+	C int `json:",string"`
+	D int `json:"d,string"`
+	E int `json:"e,omitempty"`
+	F int `json:"f,unknown,string,omitempty"`
+}
+
+func TestJSONTagOptionOrder(t *testing.T) {
+	s := (&Reflector{ExpandedStruct: true}).Reflect(&TestJSONTagOptions{})
+
+	require.Equal(t, []string{"C", "d"}, s.Required)
+	for _, name := range []string{"a", "b", "C", "d", "f"} {
+		require.Equal(t, "string", s.Properties[name].Type, name)
+	}
+	require.Equal(t, "integer", s.Properties["e"].Type)
+}
+
+func TestTupleRequiresDraft202012(t *testing.T) {
+	r := tupleReflector()
+	r.Draft = Draft07
+	require.PanicsWithValue(t, "tuple schema of event requires Draft202012", func() {
+		r.Reflect(&TestTuple{})
+	})
+}
+
+func TestPredefinedDefinitions(t *testing.T) {
+	r := &Reflector{
+		PredefinedDefinitions: map[reflect.Type]string{
+			reflect.TypeOf(Address{}): "common.json#/definitions/Address",
+		},
+	}
+	s := r.Reflect(&TestMapOfStruct{})
+
+	require.NotContains(t, s.Definitions, "Address")
+	properties := s.Definitions["TestMapOfStruct"].Properties
+	require.Equal(t, &Type{Ref: "common.json#/definitions/Address"}, properties["home"])
+	require.Equal(t, &Type{Ref: "common.json#/definitions/Address"}, properties["addresses"].PatternProperties[".*"])
+}
+
+type TestExclusive struct {
+	Age      int `json:"age" jsonschema:"minimum=18,exclusiveMinimum=true,maximum=120,exclusiveMaximum=true"`
+	Dangling int `json:"dangling" jsonschema:"exclusiveMinimum=true,maximum=10"`
+}
+
+func TestExclusiveBounds(t *testing.T) {
+	for _, draft := range []Draft{Draft04, Draft07} {
+		s := (&Reflector{ExpandedStruct: true, Draft: draft}).Reflect(&TestExclusive{})
+		dangling := s.Properties["dangling"]
+		require.Nil(t, dangling.ExclusiveMinimum)
+		require.Empty(t, dangling.Minimum)
+		require.Equal(t, json.Number("10"), dangling.Maximum)
+
+		require.PanicsWithValue(t, "exclusiveMinimum of field Dangling has no bound to make exclusive", func() {
+			(&Reflector{Draft: draft, StrictTags: true}).Reflect(&TestExclusive{})
+		})
+	}
+
+	age := (&Reflector{ExpandedStruct: true}).Reflect(&TestExclusive{}).Properties["age"]
+	require.Equal(t, json.Number("18"), age.Minimum)
+	require.Equal(t, json.RawMessage("true"), age.ExclusiveMinimum)
+	require.Equal(t, json.Number("120"), age.Maximum)
+	require.Equal(t, json.RawMessage("true"), age.ExclusiveMaximum)
+
+	age = (&Reflector{ExpandedStruct: true, Draft: Draft07}).Reflect(&TestExclusive{}).Properties["age"]
+	require.Empty(t, age.Minimum)
+	require.Equal(t, json.RawMessage("18"), age.ExclusiveMinimum)
+	require.Empty(t, age.Maximum)
+	require.Equal(t, json.RawMessage("120"), age.ExclusiveMaximum)
+}
+
+func TestRefRoundTrip(t *testing.T) {
+	for _, fixture := range []string{"fixtures/map_of_struct.json", "fixtures/ref_format_urn.json"} {
+		f, err := ioutil.ReadFile(fixture)
+		require.NoError(t, err)
+
+		s := &Schema{}
+		require.NoError(t, json.Unmarshal(f, s))
+		require.NotEmpty(t, s.Ref)
+		require.NotEmpty(t, s.Definitions["TestMapOfStruct"].Properties["home"].Ref)
+
+		dumped, err := json.Marshal(s)
+		require.NoError(t, err)
+		require.JSONEq(t, string(f), string(dumped))
+	}
+}
+
+func TestBundleOnly(t *testing.T) {
+	r := deprecatedReflector()
+	r.BundleOnly = true
+	r.ExpandedStruct = true
+	s := r.Reflect(&TestDeprecated{})
+
+	require.Equal(t, &Type{Version: Version}, s.Type)
+	require.Len(t, s.Definitions, 3)
+	for _, name := range []string{"TestDeprecated", "Address", "LegacyAddress"} {
+		require.Contains(t, s.Definitions, name)
+	}
+	require.True(t, s.Definitions["LegacyAddress"].Deprecated)
+}
+
+func TestReflectBundle(t *testing.T) {
+	s := deprecatedReflector().ReflectBundle(&TestDeprecated{}, TestUniqueItems{})
+
+	require.Equal(t, &Type{Version: Version}, s.Type)
+	require.Len(t, s.Definitions, 4)
+	for _, name := range []string{"TestDeprecated", "Address", "LegacyAddress", "TestUniqueItems"} {
+		require.Contains(t, s.Definitions, name)
+	}
+	require.True(t, s.Definitions["LegacyAddress"].Deprecated)
+	require.Contains(t, s.Definitions["TestUniqueItems"].Properties, "history")
+
+	// repeated types are bundled once
+	require.Len(t, ReflectBundle(&TestDeprecated{}, TestUniqueItems{}, &TestDeprecated{}).Definitions, 4)
+
+	// draft 2019-09 renamed definitions to $defs
+	s = (&Reflector{Draft: Draft202012}).ReflectBundle(&TestDeprecated{}, TestUniqueItems{})
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	var bundle map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(b, &bundle))
+	require.NotContains(t, bundle, "definitions")
+	var defs map[string]interface{}
+	require.NoError(t, json.Unmarshal(bundle["$defs"], &defs))
+	require.Len(t, defs, 4)
+	require.Equal(t, "#/$defs/Address", s.Definitions["TestDeprecated"].Properties["address"].Ref)
+	require.Contains(t, s.Index(), "/$defs/Address")
+
+	var unmarshaled Schema
+	require.NoError(t, json.Unmarshal(b, &unmarshaled))
+	require.Len(t, unmarshaled.Definitions, 4)
+	require.Empty(t, unmarshaled.Defs)
+}
+
+func TestUnsetKeywordsOmitted(t *testing.T) {
+	for _, typ := range []*Type{
+		{},
+		{Enum: []interface{}{}, Properties: map[string]*Type{}, AdditionalProperties: json.RawMessage{}},
+	} {
+		b, err := json.Marshal(typ)
+		require.NoError(t, err)
+		require.Equal(t, "{}", string(b))
+	}
+
+	b, err := json.Marshal(&Type{Type: "string"})
+	require.NoError(t, err)
+	require.Equal(t, `{"type":"string"}`, string(b))
+
+	// nowhere in a reflected schema is a keyword present but empty
+	b, err = json.Marshal(Reflect(&TestUser{}))
+	require.NoError(t, err)
+	var schema interface{}
+	require.NoError(t, json.Unmarshal(b, &schema))
+	requireNoEmptyKeywords(t, "#", schema)
+}
+
+func requireNoEmptyKeywords(t *testing.T, path string, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		t.Errorf("%s is null", path)
+	case string:
+		require.NotEmpty(t, v, path)
+	case []interface{}:
+		require.NotEmpty(t, v, path)
+		for i, item := range v {
+			requireNoEmptyKeywords(t, path+"/"+strconv.Itoa(i), item)
+		}
+	case map[string]interface{}:
+		require.NotEmpty(t, v, path)
+		for key, value := range v {
+			requireNoEmptyKeywords(t, path+"/"+key, value)
+		}
+	}
+}
+
+type Category struct {
+	Name     string      `json:"name"`
+	Parent   *Category   `json:"parent,omitempty"`
+	Children []*Category `json:"children,omitempty"`
+}
+
+func TestRecursiveType(t *testing.T) {
+	s := Reflect(&Category{})
+
+	require.Equal(t, "#/definitions/Category", s.Ref)
+	require.Len(t, s.Definitions, 1)
+	category := s.Definitions["Category"]
+	require.Equal(t, "#/definitions/Category", category.Properties["parent"].Ref)
+	require.Equal(t, "array", category.Properties["children"].Type)
+	require.Equal(t, "#/definitions/Category", category.Properties["children"].Items.Ref)
+	require.Equal(t, []string{"name"}, category.Required)
+}
+
+type TestSortedEnums struct {
+	Size    string      `json:"size" jsonschema:"enum=medium,enum=small,enum=large"`
+	Level   int         `json:"level" jsonschema:"enum=3,enum=1,enum=2"`
+	Mixed   interface{} `json:"mixed" jsonschema_enum:"[\"b\",1,\"a\"]"`
+	Numbers interface{} `json:"numbers" jsonschema_enum:"[2.5,-1,2]"`
+	Color   Color       `json:"color"`
+}
+
+func TestSortEnums(t *testing.T) {
+	r := stringerEnumReflector()
+	r.ExpandedStruct = true
+	r.SortEnums = true
+	s := r.Reflect(&TestSortedEnums{})
+
+	require.Equal(t, []interface{}{"large", "medium", "small"}, s.Properties["size"].Enum)
+	require.Equal(t, []interface{}{1, 2, 3}, s.Properties["level"].Enum)
+	require.Equal(t, []interface{}{"b", float64(1), "a"}, s.Properties["mixed"].Enum)
+	require.Equal(t, []interface{}{float64(-1), float64(2), 2.5}, s.Properties["numbers"].Enum)
+	require.Equal(t, []interface{}{"blue", "green", "red"}, s.Properties["color"].Enum)
+
+	r.SortEnums = false
+	s = r.Reflect(&TestSortedEnums{})
+	require.Equal(t, []interface{}{"medium", "small", "large"}, s.Properties["size"].Enum)
+	require.Equal(t, []interface{}{"red", "green", "blue"}, s.Properties["color"].Enum)
+}
+
+func TestDraftSchemaURIs(t *testing.T) {
+	expected := map[Draft]string{
+		Draft04:     "http://json-schema.org/draft-04/schema#",
+		Draft06:     "http://json-schema.org/draft-06/schema#",
+		Draft07:     "http://json-schema.org/draft-07/schema#",
+		Draft201909: "https://json-schema.org/draft/2019-09/schema",
+		Draft202012: "https://json-schema.org/draft/2020-12/schema",
+	}
+	require.Equal(t, expected, draftURIs)
+
+	for draft, uri := range expected {
+		require.Equal(t, uri, (&Reflector{Draft: draft}).Reflect(&TestUser{}).Version)
+		require.Equal(t, uri, (&Reflector{Draft: draft, ExpandedStruct: true}).Reflect(&TestUser{}).Version)
+	}
+}
+
+func TestScopedDefsRoundTrip(t *testing.T) {
+	doc := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"shipping": {
+				"$defs": {
+					"Address": {"type": "object", "properties": {"city": {"type": "string"}}}
+				},
+				"type": "array",
+				"items": {"$ref": "#/properties/shipping/$defs/Address"}
+			}
+		}
+	}`
+	s := &Schema{}
+	require.NoError(t, json.Unmarshal([]byte(doc), s))
+	require.Equal(t, "string", s.Properties["shipping"].Defs["Address"].Properties["city"].Type)
+
+	dumped, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.JSONEq(t, doc, string(dumped))
+}
+
+func TestReflectOptions(t *testing.T) {
+	r := &Reflector{}
+	s := r.Reflect(&TestUser{}, WithDraft(Draft202012), WithExpandedStruct())
+	require.Equal(t, "https://json-schema.org/draft/2020-12/schema", s.Version)
+	require.Equal(t, "object", s.Type.Type)
+	require.Empty(t, s.Ref)
+
+	require.Equal(t, &Reflector{}, r)
+	s = r.Reflect(&TestUser{})
+	require.Equal(t, Version, s.Version)
+	require.Equal(t, "#/definitions/TestUser", s.Ref)
+
+	s = Reflect(&TestUser{}, WithAllowAdditionalProperties(), WithExpandedStruct())
+	require.Equal(t, json.RawMessage("true"), s.AdditionalProperties)
+}
+
+func TestUnserializableFieldsUnderStrictTags(t *testing.T) {
+	require.PanicsWithValue(t, "field Context of unserializable type context.Context", func() {
+		(&Reflector{StrictTags: true}).Reflect(&TestUnserializable{})
+	})
+}
+
+func TestMarshalIndentStable(t *testing.T) {
+	r := &Reflector{RootExample: map[string]interface{}{"zip": "1", "city": "a", "street": "b"}}
+	expected, err := r.Reflect(&TestUser{}).MarshalIndentStable()
+	require.NoError(t, err)
+	require.True(t, strings.Index(string(expected), `"city"`) < strings.Index(string(expected), `"zip"`))
+
+	for i := 0; i < 20; i++ {
+		s := r.Reflect(&TestUser{})
+		s.Examples = []interface{}{json.RawMessage(`{"zip": "1", "street": "b", "city": "a"}`)}
+		b, err := s.MarshalIndentStable()
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(b))
+	}
+}
+
+type TestEnumBounds struct {
+	Level int `json:"level" jsonschema:"enum=5,enum=10,minimum=10"`
+}
+
+type TestEnumWithinBounds struct {
+	Level int `json:"level" jsonschema:"enum=10,enum=20,minimum=10,maximum=20"`
+}
+
+func TestEnumOutOfBoundsUnderStrictTags(t *testing.T) {
+	require.PanicsWithValue(t, "enum value 5 of field Level is out of its bounds", func() {
+		(&Reflector{StrictTags: true}).Reflect(&TestEnumBounds{})
+	})
+	require.NotPanics(t, func() {
+		(&Reflector{}).Reflect(&TestEnumBounds{})
+		(&Reflector{StrictTags: true}).Reflect(&TestEnumWithinBounds{})
+	})
+
+	excl := &Type{Maximum: "1", ExclusiveMaximum: []byte("true")}
+	require.False(t, excl.withinBounds(1))
+	require.True(t, excl.withinBounds(0.5))
+	require.False(t, (&Type{ExclusiveMinimum: []byte("0")}).withinBounds(0))
+}
+
+type TestDefaultNotInEnum struct {
+	Status string `json:"status" jsonschema:"enum=active,enum=suspended,default=deleted"`
+}
+
+type TestDefaultInEnum struct {
+	Status string `json:"status" jsonschema:"enum=active,enum=suspended,default=active"`
+	Level  int    `json:"level" jsonschema:"enum=1,enum=2,default=2"`
+	Active bool   `json:"active" jsonschema:"enum=true,default=true"`
+}
+
+func TestDefaultNotInEnumUnderStrictTags(t *testing.T) {
+	require.PanicsWithValue(t, "default deleted of field Status is not one of its enum values", func() {
+		(&Reflector{StrictTags: true}).Reflect(&TestDefaultNotInEnum{})
+	})
+	require.NotPanics(t, func() {
+		(&Reflector{}).Reflect(&TestDefaultNotInEnum{})
+		(&Reflector{StrictTags: true}).Reflect(&TestDefaultInEnum{})
+	})
+}
+
+type TestNamedFormats struct {
+	Email     string    `json:"email"`
+	Website   string    `json:"website" jsonschema:"format=hostname"`
+	CreatedAt time.Time `json:"created_at"`
+	Count     int       `json:"count"`
+	Name      string    `json:"name"`
+}
+
+func TestFormatByName(t *testing.T) {
+	formats := map[string]string{
+		"email":      "email",
+		"website":    "uri",
+		"created_at": "date",
+		"count":      "int64",
+	}
+	r := &Reflector{ExpandedStruct: true, FormatByName: func(name string) string {
+		return formats[name]
+	}}
+	s := r.Reflect(&TestNamedFormats{})
+
+	require.Equal(t, "email", s.Properties["email"].Format)
+	require.Equal(t, "hostname", s.Properties["website"].Format)
+	require.Equal(t, "date-time", s.Properties["created_at"].Format)
+	require.Empty(t, s.Properties["count"].Format)
+	require.Empty(t, s.Properties["name"].Format)
+}
+
+type TestOmitemptyName struct {
+	Flag  string `json:"omitempty"`
+	Other string `json:",omitempty"`
+}
+
+func TestOmitemptyAsName(t *testing.T) {
+	s := (&Reflector{ExpandedStruct: true}).Reflect(&TestOmitemptyName{})
+	require.Contains(t, s.Properties, "omitempty")
+	require.Contains(t, s.Properties, "Other")
+	require.Equal(t, []string{"omitempty"}, s.Required)
+}
+
+func TestFormatKinds(t *testing.T) {
+	for _, test := range []struct {
+		field   reflect.StructField
+		applies bool
+	}{
+		{reflect.StructField{Name: "At", Type: reflect.TypeOf(""), Tag: `jsonschema:"format=date-time"`}, true},
+		{reflect.StructField{Name: "ID", Type: reflect.TypeOf(""), Tag: `jsonschema:"format=custom-id"`}, true},
+		{reflect.StructField{Name: "Count", Type: reflect.TypeOf(int32(0)), Tag: `jsonschema:"format=int32"`}, true},
+		{reflect.StructField{Name: "Size", Type: reflect.TypeOf(int64(0)), Tag: `jsonschema:"format=int64"`}, true},
+		{reflect.StructField{Name: "Ratio", Type: reflect.TypeOf(0.0), Tag: `jsonschema:"format=double"`}, true},
+		{reflect.StructField{Name: "Name", Type: reflect.TypeOf(""), Tag: `jsonschema:"format=int32"`}, false},
+		{reflect.StructField{Name: "Ratio", Type: reflect.TypeOf(0.0), Tag: `jsonschema:"format=int64"`}, false},
+		{reflect.StructField{Name: "Count", Type: reflect.TypeOf(0), Tag: `jsonschema:"format=date-time"`}, false},
+		{reflect.StructField{Name: "Count", Type: reflect.TypeOf(0), Tag: `jsonschema:"format=custom-id"`}, false},
+		{reflect.StructField{Name: "Enabled", Type: reflect.TypeOf(true), Tag: `jsonschema:"format=float"`}, false},
+	} {
+		typ := reflect.StructOf([]reflect.StructField{test.field})
+		s := (&Reflector{ExpandedStruct: true}).ReflectFromType(typ)
+		property := s.Properties[test.field.Name]
+		if test.applies {
+			require.Equal(t, strings.TrimPrefix(string(test.field.Tag), `jsonschema:"format=`), property.Format+`"`)
+			require.NotPanics(t, func() {
+				(&Reflector{StrictTags: true}).ReflectFromType(typ)
+			})
+		} else {
+			require.Empty(t, property.Format)
+			require.Panics(t, func() {
+				(&Reflector{StrictTags: true}).ReflectFromType(typ)
+			})
+		}
+	}
+}
+
+func TestRootComment(t *testing.T) {
+	r := &Reflector{RootComment: "generated from models v1.2.3"}
+	s := r.Reflect(&TestUser{})
+	require.Equal(t, "generated from models v1.2.3", s.Comment)
+	require.Empty(t, s.Definitions["TestUser"].Comment)
+
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"$comment":"generated from models v1.2.3"`)
+
+	r.ExpandedStruct = true
+	require.Equal(t, "generated from models v1.2.3", r.Reflect(&TestUser{}).Comment)
+	require.Empty(t, Reflect(&TestUser{}).Comment)
+}
+
+type TimestampAlias = time.Time
+
+type NamedTimestamp time.Time
+
+type TestTimeAlias struct {
+	Alias TimestampAlias `json:"alias"`
+	Named NamedTimestamp `json:"named"`
+}
+
+func TestTimeAliases(t *testing.T) {
+	s := (&Reflector{ExpandedStruct: true}).Reflect(&TestTimeAlias{})
+	require.Equal(t, &Type{Type: "string", Format: "date-time"}, s.Properties["alias"])
+
+	// named types do not marshal like time.Time, unless they say so
+	require.Equal(t, "#/definitions/NamedTimestamp", s.Properties["named"].Ref)
+	require.Equal(t, "object", s.Definitions["NamedTimestamp"].Type)
+	require.Empty(t, s.Definitions["NamedTimestamp"].Properties)
+
+	r := &Reflector{ExpandedStruct: true, TypeMapper: func(t reflect.Type) *Type {
+		if t == reflect.TypeOf(NamedTimestamp{}) {
+			return &Type{Type: "string", Format: "date-time"}
+		}
+		return nil
+	}}
+	s = r.Reflect(&TestTimeAlias{})
+	require.Equal(t, s.Properties["alias"], s.Properties["named"])
+}
+
+type TestPointerShapes struct {
+	List  []*Address          `json:"list"`
+	Index map[string]*Address `json:"index"`
+	Batch *[]Address          `json:"batch,omitempty"`
+	Grid  [][]*Address        `json:"grid"`
+}
+
+func TestPointerComposites(t *testing.T) {
+	s := Reflect(&TestPointerShapes{})
+	require.Len(t, s.Definitions, 2)
+	require.Contains(t, s.Definitions, "Address")
+
+	properties := s.Definitions["TestPointerShapes"].Properties
+	for _, ref := range []*Type{
+		properties["list"].Items,
+		properties["index"].PatternProperties[".*"],
+		properties["batch"].Items,
+		properties["grid"].Items.Items,
+	} {
+		require.Equal(t, "#/definitions/Address", ref.Ref)
+	}
+	require.Equal(t, "array", properties["batch"].Type)
+}
+
+func TestHumanize(t *testing.T) {
+	for name, expected := range map[string]string{
+		"birth_date":       "Birth Date",
+		"network-address":  "Network Address",
+		"someBaseProperty": "Some Base Property",
+		"userID":           "User ID",
+		"HTTPServer":       "HTTP Server",
+		"TestFlag":         "Test Flag",
+		"ipv4":             "Ipv4",
+		"__private":        "Private",
+	} {
+		require.Equal(t, expected, humanize(name), name)
+	}
+}
+
+func TestMaxProperties(t *testing.T) {
+	require.PanicsWithValue(t, "jsonschema.Address has 2 properties, more than MaxProperties 1", func() {
+		(&Reflector{MaxProperties: 1}).Reflect(&TestMapOfStruct{})
+	})
+	require.Panics(t, func() {
+		(&Reflector{MaxProperties: 1, ExpandedStruct: true}).Reflect(&Address{})
+	})
+	require.NotPanics(t, func() {
+		(&Reflector{MaxProperties: 2}).Reflect(&Address{})
+		(&Reflector{}).Reflect(&TestUser{})
+	})
+}
+
+func TestExpandedStructRequired(t *testing.T) {
+	for _, v := range []interface{}{&TestUser{}, &TestRequired{}, &TestShadowedFields{}, &TestStructOmitempty{}} {
+		expanded := (&Reflector{ExpandedStruct: true}).Reflect(v)
+		referenced := (&Reflector{}).Reflect(v)
+		name := strings.TrimPrefix(referenced.Ref, "#/definitions/")
+		require.Equal(t, referenced.Definitions[name].Required, expanded.Required, name)
+	}
+
+	s := (&Reflector{ExpandedStruct: true}).Reflect(&TestOmitEmptyTime{})
+	require.Equal(t, []string{"deadline"}, s.Required)
+}
+
+func TestDurationPattern(t *testing.T) {
+	pattern := regexp.MustCompile(durationPattern)
+	for _, d := range []time.Duration{0, time.Nanosecond, 1500 * time.Microsecond, -90 * time.Second, 26*time.Hour + 3*time.Minute} {
+		require.Regexp(t, pattern, d.String())
+	}
+	require.NotRegexp(t, pattern, "1d")
+	require.NotRegexp(t, pattern, "")
+}
+
+type Weekday int
+
+const (
+	Monday Weekday = iota
+	Tuesday
+	Wednesday
+)
+
+type TestIotaEnum struct {
+	Day     Weekday   `json:"day"`
+	Holiday *Weekday  `json:"holiday,omitempty"`
+	Days    []Weekday `json:"days"`
+}
+
+func TestRegisterIotaEnum(t *testing.T) {
+	r := &Reflector{ExpandedStruct: true}
+	r.RegisterIotaEnum(Weekday(0), []string{"monday", "tuesday", "wednesday"})
+	s := r.Reflect(&TestIotaEnum{})
+
+	expected := &Type{Type: "string", Enum: []interface{}{"monday", "tuesday", "wednesday"}}
+	require.Equal(t, expected, s.Properties["day"])
+	require.Equal(t, expected, s.Properties["holiday"])
+	require.Equal(t, expected, s.Properties["days"].Items)
+
+	require.PanicsWithValue(t, "enum type string is not an integer type", func() {
+		r.RegisterIotaEnum("", []string{"a"})
+	})
+}
+
+func TestReflectPair(t *testing.T) {
+	full, minimal := (&Reflector{}).ReflectPair(&TestUser{})
+
+	name := full.Definitions["TestUser"].Properties["name"]
+	require.Equal(t, "the name", name.Title)
+	require.Equal(t, "this is a property", name.Description)
+	require.Equal(t, []interface{}{"joe", "lucy"}, name.Examples)
+
+	minimalName := minimal.Definitions["TestUser"].Properties["name"]
+	require.Empty(t, minimalName.Title)
+	require.Empty(t, minimalName.Description)
+	require.Empty(t, minimalName.Examples)
+
+	require.Equal(t, full.Ref, minimal.Ref)
+	require.Equal(t, full.Definitions["TestUser"].Required, minimal.Definitions["TestUser"].Required)
+	require.Equal(t, name.Type, minimalName.Type)
+	require.Equal(t, name.MinLength, minimalName.MinLength)
+	require.Equal(t, name.MaxLength, minimalName.MaxLength)
+	require.Equal(t, name.Pattern, minimalName.Pattern)
+	require.Equal(t, name.Default, minimalName.Default)
+	require.Equal(t, len(full.Definitions["TestUser"].Properties), len(minimal.Definitions["TestUser"].Properties))
+}
+
+func TestDeprecationNoteStrict(t *testing.T) {
+	require.PanicsWithValue(t, "deprecationNote of field Timeout is not deprecated", func() {
+		(&Reflector{StrictTags: true}).Reflect(&TestDeprecationNote{})
+	})
+}
+
+func TestDuplicateDefinitionNames(t *testing.T) {
+	type Order struct {
+		Billing Address `json:"billing"`
+	}
+	// a distinct type of the same name as the package level Address
+	type Address struct {
+		Line string `json:"line"`
+	}
+	type Shipment struct {
+		Order Order     `json:"order"`
+		To    Address   `json:"to"`
+		Stops []Address `json:"stops"`
+	}
+
+	s := (&Reflector{}).Reflect(&Shipment{})
+	require.Equal(t, "#/definitions/Address", s.Definitions["Order"].Properties["billing"].Ref)
+	require.Contains(t, s.Definitions["Address"].Properties, "city")
+	require.Equal(t, "#/definitions/Address2", s.Definitions["Shipment"].Properties["to"].Ref)
+	require.Equal(t, "#/definitions/Address2", s.Definitions["Shipment"].Properties["stops"].Items.Ref)
+	require.Contains(t, s.Definitions["Address2"].Properties, "line")
+	require.Len(t, s.Definitions, 4)
+
+	// types local to a function share their qualified name too
+	s = (&Reflector{DefinitionNameWithPackage: true}).Reflect(&Shipment{})
+	require.Contains(t, s.Definitions, "jsonschema.Address2")
+	require.Len(t, s.Definitions, 4)
+}
+
+type TestReopenedNode struct {
+	Value string            `json:"value"`
+	Child *TestReopenedNode `json:"child,omitempty" jsonschema:"additionalProperties=true"`
+}
+
+func TestReopenedRecursiveStruct(t *testing.T) {
+	s := (&Reflector{}).Reflect(&TestReopenedNode{})
+	_, err := json.Marshal(s)
+	require.NoError(t, err)
+
+	node := s.Definitions["TestReopenedNode"]
+	require.Equal(t, json.RawMessage("false"), node.AdditionalProperties)
+	require.Equal(t, "#/definitions/TestReopenedNodeOpen", node.Properties["child"].Ref)
+
+	reopened := s.Definitions["TestReopenedNodeOpen"]
+	require.Equal(t, json.RawMessage("true"), reopened.AdditionalProperties)
+	require.Equal(t, []string{"value"}, reopened.Required)
+	require.Equal(t, "#/definitions/TestReopenedNodeOpen", reopened.Properties["child"].Ref)
+
+	var paths []string
+	s.Walk(func(path string, _ *Type) {
+		paths = append(paths, path)
+	})
+	require.Len(t, paths, 7)
+}
+
+func TestWellKnownRefsNameCollision(t *testing.T) {
+	type Timestamp struct {
+		Seconds int64 `json:"seconds"`
+	}
+	type Event struct {
+		At      time.Time `json:"at"`
+		Logical Timestamp `json:"logical"`
+	}
+	type Entry struct {
+		Logical Timestamp `json:"logical"`
+		At      time.Time `json:"at"`
+	}
+
+	s := (&Reflector{WellKnownRefs: true}).Reflect(&Event{})
+	require.Equal(t, "#/definitions/Timestamp", s.Definitions["Event"].Properties["at"].Ref)
+	require.Equal(t, "date-time", s.Definitions["Timestamp"].Format)
+	require.Equal(t, "#/definitions/Timestamp2", s.Definitions["Event"].Properties["logical"].Ref)
+	require.Contains(t, s.Definitions["Timestamp2"].Properties, "seconds")
+
+	s = (&Reflector{WellKnownRefs: true}).Reflect(&Entry{})
+	require.Equal(t, "#/definitions/Timestamp", s.Definitions["Entry"].Properties["logical"].Ref)
+	require.Contains(t, s.Definitions["Timestamp"].Properties, "seconds")
+	require.Equal(t, "#/definitions/Timestamp2", s.Definitions["Entry"].Properties["at"].Ref)
+	require.Equal(t, "date-time", s.Definitions["Timestamp2"].Format)
+
+	s = (&Reflector{WellKnownRefs: true, RefFormat: URN, RefBase: "urn:example"}).Reflect(&TestWellKnownRefs{})
+	require.Equal(t, "urn:example:Timestamp", s.Definitions["Timestamp"].ID)
+}
+
+func TestTypedAdditionalPropertiesStrict(t *testing.T) {
+	r := typedAdditionalPropertiesReflector()
+	r.StrictTags = true
+	require.PanicsWithValue(t, "additionalProperties of field Unknown names unregistered type Unregistered", func() {
+		r.Reflect(&TestTypedAdditionalProperties{})
+	})
+}
+
+func TestUnixTimeBoundsStrict(t *testing.T) {
+	type Fractional struct {
+		ExpiresAt int64 `json:"expires_at" jsonschema:"exclusiveMinimum=1600000000.5"`
+	}
+	type Conflicting struct {
+		UpdatedAt int64 `json:"updated_at" jsonschema:"minimum=1600000000,exclusiveMinimum=1700000000"`
+	}
+
+	require.PanicsWithValue(t, "exclusiveMinimum 1600000000.5 of integer field ExpiresAt is not an integer", func() {
+		(&Reflector{StrictTags: true, Draft: Draft07}).Reflect(&Fractional{})
+	})
+	require.PanicsWithValue(t, "exclusiveMinimum of field UpdatedAt conflicts with its minimum in draft-04", func() {
+		(&Reflector{StrictTags: true}).Reflect(&Conflicting{})
+	})
+	require.NotPanics(t, func() {
+		(&Reflector{StrictTags: true, Draft: Draft07}).Reflect(&Conflicting{})
+	})
+
+	type Infinite struct {
+		Forever int64 `json:"forever" jsonschema:"exclusiveMaximum=Inf"`
+	}
+	require.PanicsWithValue(t, "exclusiveMaximum Inf of field Forever is not a finite number", func() {
+		(&Reflector{StrictTags: true, Draft: Draft07}).Reflect(&Infinite{})
+	})
+}
+
+func TestNumberFormsStrict(t *testing.T) {
+	require.PanicsWithValue(t, "minimum -Inf of field Infinite is not a finite number", func() {
+		(&Reflector{StrictTags: true}).Reflect(&TestNumberForms{})
+	})
+}
+
+func TestTimeDescriptionComments(t *testing.T) {
+	r := &Reflector{TimeDescription: "RFC 3339 timestamp", CommentMap: map[string]string{
+		"github.com/megaease/jsonschema.TestTimeDescription.CreatedAt": "when the account was created",
+	}}
+	properties := r.Reflect(&TestTimeDescription{}).Definitions["TestTimeDescription"].Properties
+	require.Equal(t, "when the account was created", properties["created_at"].Description)
+	require.Equal(t, "RFC 3339 timestamp", properties["updated_at"].Description)
+	require.Equal(t, "RFC 3339 timestamp", properties["history"].Items.Description)
+	require.Equal(t, "when the token expires", properties["expires_at"].Description)
+}
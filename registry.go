@@ -0,0 +1,76 @@
+package jsonschema
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// A SchemaRegistry holds schemas by their $id, so that references between
+// them can be followed. The zero value is an empty registry ready to use.
+type SchemaRegistry struct {
+	schemas map[string]*Schema
+	ids     map[string]*Type
+}
+
+// Register adds s to the registry under its $id, along with any schema
+// within it declaring an $id of its own, such as definitions reflected with
+// the AbsoluteURL RefFormat.
+func (sr *SchemaRegistry) Register(s *Schema) error {
+	if s.Type == nil || s.ID == "" {
+		return fmt.Errorf("schema has no $id")
+	}
+	id := strings.TrimSuffix(s.ID, "#")
+	if _, ok := sr.schemas[id]; ok {
+		return fmt.Errorf("schema %s is already registered", id)
+	}
+	if sr.schemas == nil {
+		sr.schemas = map[string]*Schema{}
+		sr.ids = map[string]*Type{}
+	}
+	sr.schemas[id] = s
+	s.Walk(func(_ string, t *Type) {
+		if t.ID != "" {
+			sr.ids[strings.TrimSuffix(t.ID, "#")] = t
+		}
+	})
+	return nil
+}
+
+// Resolve returns the schema registered with the $id id.
+func (sr *SchemaRegistry) Resolve(id string) (*Schema, error) {
+	s, ok := sr.schemas[strings.TrimSuffix(id, "#")]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %s", id)
+	}
+	return s, nil
+}
+
+// ResolveRef returns the schema an absolute $ref refers to, made of the $id
+// of a registered schema, or of a schema within it, optionally followed by
+// a JSON pointer fragment, e.g. "https://example.com/user.json#/definitions/User".
+func (sr *SchemaRegistry) ResolveRef(ref string) (*Type, error) {
+	id, fragment := ref, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		id, fragment = ref[:i], ref[i+1:]
+	}
+
+	var root *Schema
+	if s, ok := sr.schemas[id]; ok {
+		root = s
+	} else if t, ok := sr.ids[id]; ok {
+		root = &Schema{Type: t}
+	} else {
+		return nil, fmt.Errorf("unknown schema %s", id)
+	}
+
+	pointer, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %s: %v", ref, err)
+	}
+	t, ok := root.Index()[pointer]
+	if !ok {
+		return nil, fmt.Errorf("unknown reference %s", ref)
+	}
+	return t, nil
+}
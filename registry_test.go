@@ -0,0 +1,61 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type Customer struct {
+	Name    string      `json:"name"`
+	Address interface{} `json:"address"`
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	r := &Reflector{RefFormat: AbsoluteURL, RefBase: "https://example.com/schemas/address"}
+	addresses := r.Reflect(&Address{})
+	addresses.ID = "https://example.com/schemas/address.json"
+
+	r = &Reflector{}
+	r.AddFieldSchema(Customer{}, "address", &Type{Ref: "https://example.com/schemas/address/Address"})
+	customers := r.Reflect(&Customer{})
+	customers.ID = "https://example.com/schemas/customer.json#"
+	customers.Definitions["Customer"].Properties["referrer"] = &Type{Ref: "https://example.com/schemas/customer.json#/definitions/Customer"}
+
+	sr := &SchemaRegistry{}
+	require.NoError(t, sr.Register(addresses))
+	require.NoError(t, sr.Register(customers))
+	require.Error(t, sr.Register(customers))
+	require.Error(t, sr.Register(&Schema{Type: &Type{}}))
+
+	s, err := sr.Resolve("https://example.com/schemas/customer.json")
+	require.NoError(t, err)
+	require.Same(t, customers, s)
+	_, err = sr.Resolve("https://example.com/schemas/order.json")
+	require.Error(t, err)
+
+	// relative references are not resolved
+	_, err = sr.ResolveRef(customers.Ref)
+	require.Error(t, err)
+
+	// the customer schema refers to the address schema by the $id of its
+	// definition, and to itself by JSON pointer
+	customer, err := sr.ResolveRef(customers.ID + customers.Ref[1:])
+	require.NoError(t, err)
+	require.Same(t, customers.Definitions["Customer"], customer)
+
+	address, err := sr.ResolveRef(customer.Properties["address"].Ref)
+	require.NoError(t, err)
+	require.Same(t, addresses.Definitions["Address"], address)
+
+	city, err := sr.ResolveRef("https://example.com/schemas/address/Address#/properties/city")
+	require.NoError(t, err)
+	require.Equal(t, "string", city.Type)
+
+	referrer, err := sr.ResolveRef(customer.Properties["referrer"].Ref)
+	require.NoError(t, err)
+	require.Same(t, customer, referrer)
+
+	_, err = sr.ResolveRef("https://example.com/schemas/address.json#/definitions/Missing")
+	require.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package jsonschema
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Walk calls fn for every schema of s, the root included, depth first. The
+// path of each schema is its JSON pointer from the root, "" being the root
+// itself, e.g. "/definitions/User/properties/name", or "/$defs/User/..."
+// from draft 2019-09 on.
+func (s *Schema) Walk(fn func(path string, t *Type)) {
+	if s.Type != nil {
+		walkType("", s.Type, fn)
+	}
+	walkTypeMap("/"+s.definitionsKeyword(), s.Definitions, fn)
+}
+
+// Index returns every schema of s keyed by its JSON pointer, see Walk.
+func (s *Schema) Index() map[string]*Type {
+	index := map[string]*Type{}
+	s.Walk(func(path string, t *Type) {
+		index[path] = t
+	})
+	return index
+}
+
+func walkType(path string, t *Type, fn func(string, *Type)) {
+	if t == nil {
+		return
+	}
+	fn(path, t)
+
+	walkTypeMap(path+"/$defs", t.Defs, fn)
+	walkTypeMap(path+"/definitions", t.Definitions, fn)
+	walkTypeMap(path+"/properties", t.Properties, fn)
+	walkTypeMap(path+"/patternProperties", t.PatternProperties, fn)
+	walkTypeMap(path+"/dependencies", t.Dependencies, fn)
+	walkType(path+"/propertyNames", t.PropertyNames, fn)
+	walkType(path+"/items", t.Items, fn)
+	walkType(path+"/additionalItems", t.AdditionalItems, fn)
+	walkTypeSlice(path+"/prefixItems", t.PrefixItems, fn)
+	walkTypeSlice(path+"/allOf", t.AllOf, fn)
+	walkTypeSlice(path+"/anyOf", t.AnyOf, fn)
+	walkTypeSlice(path+"/oneOf", t.OneOf, fn)
+	walkType(path+"/not", t.Not, fn)
+	walkType(path+"/if", t.If, fn)
+	walkType(path+"/then", t.Then, fn)
+	walkType(path+"/else", t.Else, fn)
+	walkType(path+"/contentSchema", t.ContentSchema, fn)
+	walkType(path+"/media", t.Media, fn)
+}
+
+func walkTypeMap(path string, types map[string]*Type, fn func(string, *Type)) {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		walkType(path+"/"+escapeJSONPointer(name), types[name], fn)
+	}
+}
+
+func walkTypeSlice(path string, types []*Type, fn func(string, *Type)) {
+	for i, t := range types {
+		walkType(path+"/"+strconv.Itoa(i), t, fn)
+	}
+}
+
+// escapeJSONPointer escapes a JSON pointer reference token.
+// RFC 6901, section 3
+func escapeJSONPointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,33 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	s := Reflect(&TestUser{})
+	index := s.Index()
+
+	require.Same(t, s.Type, index[""])
+	require.Same(t, s.Definitions["TestUser"], index["/definitions/TestUser"])
+	require.Equal(t, "string", index["/definitions/TestUser/properties/name"].Type)
+	require.Equal(t, "integer", index["/definitions/TestUser/properties/some_base_property"].Type)
+	require.Equal(t, "array", index["/definitions/TestUser/properties/friends"].Type)
+	require.Equal(t, "integer", index["/definitions/TestUser/properties/friends/items"].Type)
+	require.Equal(t, "#/definitions/GrandfatherType", index["/definitions/TestUser/properties/grand"].Ref)
+	require.Equal(t, "string", index["/definitions/GrandfatherType/properties/family_name"].Type)
+	require.Equal(t, "integer", index["/definitions/TestUser/properties/feeling/oneOf/1"].Type)
+	require.Equal(t, "base64", index["/definitions/TestUser/properties/photo/media"].BinaryEncoding)
+	require.Contains(t, index, "/definitions/TestUser/properties/tags/patternProperties/.*")
+}
+
+func TestWalkEscapesPointers(t *testing.T) {
+	s := &Schema{Type: &Type{PatternProperties: map[string]*Type{"^a/~b$": {Type: "string"}}}}
+	var paths []string
+	s.Walk(func(path string, _ *Type) {
+		paths = append(paths, path)
+	})
+	require.Equal(t, []string{"", "/patternProperties/^a~1~0b$"}, paths)
+}